@@ -0,0 +1,200 @@
+package sdf
+
+import (
+	"math"
+	"sort"
+
+	"github.com/soypat/sdf/internal/d2"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// bvhMinChildren is the number of children from which unions are evaluated
+// using a bounding volume hierarchy. Smaller unions evaluate every child.
+const bvhMinChildren = 4
+
+// bvhNode is a node of a bounding volume hierarchy stored in a slice.
+// For leaf nodes left is -1 and right is the index of the SDF in the union.
+// Internal nodes store the slice indices of their child nodes.
+type bvhNode struct {
+	left, right int
+}
+
+// bvhSkip reports whether a child whose bounding box is at squared distance
+// dist2 from the evaluation point can be skipped given the closest child
+// distance found so far. The distance to the bounding box is a lower bound
+// of the child's distance when the point lies outside of it.
+func bvhSkip(closest, dist2 float64) bool {
+	if dist2 == 0 || math.IsInf(closest, 1) {
+		// Point is within bounding box or no child evaluated yet.
+		return false
+	}
+	return math.Sqrt(dist2) >= closest
+}
+
+// bvh3 is a bounding volume hierarchy over the bounding boxes of
+// the children of an SDF3 union.
+type bvh3 struct {
+	nodes []bvhNode
+	boxes []d3.Box
+}
+
+// newBVH3 builds a bounding volume hierarchy over the bounds of sdf.
+func newBVH3(sdf []SDF3) *bvh3 {
+	t := &bvh3{
+		nodes: make([]bvhNode, 0, 2*len(sdf)-1),
+		boxes: make([]d3.Box, 0, 2*len(sdf)-1),
+	}
+	bounds := make([]d3.Box, len(sdf))
+	idx := make([]int, len(sdf))
+	for i := range sdf {
+		bounds[i] = d3.Box(sdf[i].Bounds())
+		idx[i] = i
+	}
+	t.build(bounds, idx)
+	return t
+}
+
+// build adds the subtree over the bounds indexed by idx
+// and returns the index of its root node.
+func (t *bvh3) build(bounds []d3.Box, idx []int) int {
+	n := len(t.nodes)
+	bb := bounds[idx[0]]
+	c := bb.Center()
+	centers := d3.Box{Min: c, Max: c}
+	for _, i := range idx[1:] {
+		bb = bb.Extend(bounds[i])
+		centers = centers.Include(bounds[i].Center())
+	}
+	t.nodes = append(t.nodes, bvhNode{left: -1, right: idx[0]})
+	t.boxes = append(t.boxes, bb)
+	if len(idx) == 1 {
+		return n
+	}
+	// Split at the median along the axis with the largest spread of centers.
+	size := centers.Size()
+	key := func(b d3.Box) float64 { return b.Min.X + b.Max.X }
+	if size.Y > size.X && size.Y >= size.Z {
+		key = func(b d3.Box) float64 { return b.Min.Y + b.Max.Y }
+	} else if size.Z > size.X && size.Z > size.Y {
+		key = func(b d3.Box) float64 { return b.Min.Z + b.Max.Z }
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return key(bounds[idx[i]]) < key(bounds[idx[j]])
+	})
+	mid := len(idx) / 2
+	left := t.build(bounds, idx[:mid])
+	right := t.build(bounds, idx[mid:])
+	t.nodes[n] = bvhNode{left: left, right: right}
+	return n
+}
+
+// evaluate returns the minimum distance to the children of sdf
+// at p evaluating only those which may be the closest.
+func (t *bvh3) evaluate(sdf []SDF3, p r3.Vec) float64 {
+	var stackBuf [64]int
+	stack := append(stackBuf[:0], 0)
+	closest := math.Inf(1)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if bvhSkip(closest, t.boxes[n].Dist2(p)) {
+			continue
+		}
+		node := t.nodes[n]
+		if node.left < 0 {
+			closest = math.Min(closest, sdf[node.right].Evaluate(p))
+			continue
+		}
+		// Visit the closest subtree first so the other is more likely skipped.
+		if t.boxes[node.left].Dist2(p) < t.boxes[node.right].Dist2(p) {
+			stack = append(stack, node.right, node.left)
+		} else {
+			stack = append(stack, node.left, node.right)
+		}
+	}
+	return closest
+}
+
+// bvh2 is a bounding volume hierarchy over the bounding boxes of
+// the children of an SDF2 union.
+type bvh2 struct {
+	nodes []bvhNode
+	boxes []d2.Box
+}
+
+// newBVH2 builds a bounding volume hierarchy over the bounds of sdf.
+func newBVH2(sdf []SDF2) *bvh2 {
+	t := &bvh2{
+		nodes: make([]bvhNode, 0, 2*len(sdf)-1),
+		boxes: make([]d2.Box, 0, 2*len(sdf)-1),
+	}
+	bounds := make([]d2.Box, len(sdf))
+	idx := make([]int, len(sdf))
+	for i := range sdf {
+		bounds[i] = d2.Box(sdf[i].Bounds())
+		idx[i] = i
+	}
+	t.build(bounds, idx)
+	return t
+}
+
+// build adds the subtree over the bounds indexed by idx
+// and returns the index of its root node.
+func (t *bvh2) build(bounds []d2.Box, idx []int) int {
+	n := len(t.nodes)
+	bb := bounds[idx[0]]
+	c := bb.Center()
+	centers := d2.Box{Min: c, Max: c}
+	for _, i := range idx[1:] {
+		bb = bb.Extend(bounds[i])
+		centers = centers.Include(bounds[i].Center())
+	}
+	t.nodes = append(t.nodes, bvhNode{left: -1, right: idx[0]})
+	t.boxes = append(t.boxes, bb)
+	if len(idx) == 1 {
+		return n
+	}
+	// Split at the median along the axis with the largest spread of centers.
+	size := centers.Size()
+	key := func(b d2.Box) float64 { return b.Min.X + b.Max.X }
+	if size.Y > size.X {
+		key = func(b d2.Box) float64 { return b.Min.Y + b.Max.Y }
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return key(bounds[idx[i]]) < key(bounds[idx[j]])
+	})
+	mid := len(idx) / 2
+	left := t.build(bounds, idx[:mid])
+	right := t.build(bounds, idx[mid:])
+	t.nodes[n] = bvhNode{left: left, right: right}
+	return n
+}
+
+// evaluate returns the minimum distance to the children of sdf
+// at p evaluating only those which may be the closest.
+func (t *bvh2) evaluate(sdf []SDF2, p r2.Vec) float64 {
+	var stackBuf [64]int
+	stack := append(stackBuf[:0], 0)
+	closest := math.Inf(1)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if bvhSkip(closest, t.boxes[n].Dist2(p)) {
+			continue
+		}
+		node := t.nodes[n]
+		if node.left < 0 {
+			closest = math.Min(closest, sdf[node.right].Evaluate(p))
+			continue
+		}
+		// Visit the closest subtree first so the other is more likely skipped.
+		if t.boxes[node.left].Dist2(p) < t.boxes[node.right].Dist2(p) {
+			stack = append(stack, node.right, node.left)
+		} else {
+			stack = append(stack, node.left, node.right)
+		}
+	}
+	return closest
+}
@@ -0,0 +1,109 @@
+package sdf_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/internal/d2"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestUnionBVH(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var (
+		spheres []sdf.SDF3
+		circles []sdf.SDF2
+	)
+	for i := 0; i < 200; i++ {
+		r := 0.1 + rng.Float64()
+		s := must3.Sphere(r)
+		c := must2.Circle(r)
+		pos := r3.Vec{X: 20 * rng.Float64(), Y: 20 * rng.Float64(), Z: 2 * rng.Float64()}
+		spheres = append(spheres, sdf.Transform3D(s, sdf.Translate3D(pos)))
+		circles = append(circles, sdf.Transform2D(c, sdf.Translate2D(r2.Vec{X: pos.X, Y: pos.Y})))
+	}
+	// Blended 2D unions skip children using the distance ranges of their
+	// bounding boxes which can leave out overlapping children that blend.
+	testUnionFold(t, "overlapping", spheres, circles, false)
+
+	spheres, circles = spheres[:0], circles[:0]
+	for _, i := range rng.Perm(50) {
+		x := 3 * float64(i)
+		spheres = append(spheres, sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: x})))
+		circles = append(circles, sdf.Transform2D(must2.Circle(1), sdf.Translate2D(r2.Vec{X: x})))
+	}
+	testUnionFold(t, "spaced", spheres, circles, true)
+}
+
+// testUnionFold checks unions of spheres and circles evaluate like
+// blending every child in order with math.Min and with SetMin blends.
+func testUnionFold(t *testing.T, name string, spheres []sdf.SDF3, circles []sdf.SDF2, blend2D bool) {
+	t.Helper()
+	const tol = 1e-12
+	for k, min := range []sdf.MinFunc{nil, sdf.MinPoly(2, 0.5), sdf.MinRound(0.3), sdf.MinExp(32)} {
+		u3 := sdf.Union3D(spheres...)
+		u2 := sdf.Union2D(circles...)
+		if min != nil {
+			u3.SetMin(min)
+			u2.SetMin(min)
+		} else {
+			min = math.Min
+		}
+		bb3 := d3.Box(u3.Bounds()).ScaleAboutCenter(1.2)
+		bb2 := d2.Box(u2.Bounds()).ScaleAboutCenter(1.2)
+		values := make([]float64, len(spheres))
+		for i := 0; i < 2000; i++ {
+			p3 := bb3.Random()
+			for j, s := range spheres {
+				values[j] = s.Evaluate(p3)
+			}
+			want := foldMin(min, values)
+			if got := u3.Evaluate(p3); math.Abs(got-want) > tol {
+				t.Fatalf("%s 3D union %d at %v: got %g, want %g", name, k, p3, got, want)
+			}
+			if k > 0 && !blend2D {
+				continue
+			}
+			p2 := bb2.Random()
+			for j, c := range circles {
+				values[j] = c.Evaluate(p2)
+			}
+			want = foldMin(min, values)
+			if got := u2.Evaluate(p2); math.Abs(got-want) > tol {
+				t.Fatalf("%s 2D union %d at %v: got %g, want %g", name, k, p2, got, want)
+			}
+		}
+	}
+}
+
+// foldMin blends values in order like a union evaluating every child.
+func foldMin(min sdf.MinFunc, values []float64) float64 {
+	d := values[0]
+	for _, v := range values[1:] {
+		d = min(d, v)
+	}
+	return d
+}
+
+func BenchmarkUnionBVH(b *testing.B) {
+	s := must3.Cylinder(1, 0.2, 0)
+	var positions d3.Set
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			positions = append(positions, r3.Vec{X: float64(i), Y: float64(j)})
+		}
+	}
+	u := sdf.Multi3D(s, positions)
+	bb := d3.Box(u.Bounds())
+	points := bb.RandomSet(1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		u.Evaluate(points[i%len(points)])
+	}
+}
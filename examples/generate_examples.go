@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"image/png"
 	"io"
 	"log"
 	"os"
@@ -10,9 +11,9 @@ import (
 	"text/template"
 	"time"
 
-	"github.com/fogleman/fauxgl"
-	"github.com/nfnt/resize"
 	"github.com/soypat/sdf/internal/d3"
+	"github.com/soypat/sdf/render"
+	"github.com/soypat/sdf/render/rendertest"
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
@@ -27,7 +28,7 @@ var examples = []struct {
 	Name      string
 	Dir       string
 	resultSTL string
-	view      viewConfig
+	view      rendertest.View
 
 	// Following values Set during execution
 
@@ -119,62 +120,31 @@ func main() {
 	template.Must(template.New("examples").Parse(string(b))).Execute(output, examples)
 }
 
-var defaultView = viewConfig{
-	up:     r3.Vec{Z: 1},
-	eyepos: d3.Elem(2.4), // iso view.
-	near:   1,
-	far:    10,
+var defaultView = rendertest.View{
+	Up:     r3.Vec{Z: 1},
+	Eye:    d3.Elem(2.4), // iso view.
+	Near:   1,
+	Far:    10,
+	Width:  width,
+	Height: height,
 }
 
-func stlToPNG(stlName, outputname string, view viewConfig) error {
-	mesh, err := fauxgl.LoadSTL(stlName)
+func stlToPNG(stlName, outputname string, view rendertest.View) error {
+	fp, err := os.Open(stlName)
 	if err != nil {
 		return err
 	}
-	const (
-		scale = 1  // optional supersampling
-		fovy  = 30 // vertical field of view in degrees
-	)
-
-	var (
-		far    = view.far
-		near   = view.near
-		eye    = fauxgl.V(view.eyepos.X, view.eyepos.Y, view.eyepos.Z) // camera position
-		center = fauxgl.V(view.lookat.X, view.lookat.Y, view.lookat.Z) // view center position
-		up     = fauxgl.V(view.up.X, view.up.Y, view.up.Z)             // up vector
-		light  = fauxgl.V(-0.75, 1, 0.25).Normalize()                  // light direction
-		color  = fauxgl.HexColor("#468966")                            // object color
-	)
-
-	// fit mesh in a bi-unit cube centered at the origin
-	mesh.BiUnitCube()
-	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
-	context.ClearColorBufferWith(fauxgl.HexColor("#FFF8E3"))
-	// create transformation matrix and light direction
-	aspect := float64(width) / float64(height)
-	matrix := fauxgl.LookAt(eye, center, up).Perspective(fovy, aspect, near, far)
-	// use builtin phong shader
-	shader := fauxgl.NewPhongShader(matrix, light, eye)
-	shader.ObjectColor = color
-	context.Shader = shader
-	// render
-	context.DrawMesh(mesh)
-	// downsample image for antialiasing
-	image := context.Image()
-	image = resize.Resize(uint(width), uint(height), image, resize.Bilinear)
-	return fauxgl.SavePNG(outputname, image)
-}
-
-type viewConfig struct {
-	// what position (point) to look at
-	lookat r3.Vec
-	// which way is up (direction)
-	up r3.Vec
-	// where the camera/eye located at (point)
-	eyepos r3.Vec
-	far    float64
-	near   float64
+	defer fp.Close()
+	model, err := render.ReadSTL(fp)
+	if err != nil {
+		return err
+	}
+	output, err := os.Create(outputname)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	return png.Encode(output, rendertest.Image(model, view))
 }
 
 func getHumanSize(fileName string) (size string) {
@@ -0,0 +1,24 @@
+package form2
+
+import (
+	"image"
+	"runtime/debug"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+)
+
+// Image returns the SDF2 of the pixels of img selected by mode with a
+// threshold between 0 and 1. Pixels are squares of side pixelSize and the
+// image is centered at the origin with the Y axis pointing up.
+func Image(img image.Image, pixelSize float64, mode must2.ImageMode, threshold float64) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must2.Image(img, pixelSize, mode, threshold), err
+}
@@ -0,0 +1,445 @@
+package must2
+
+import (
+	"math"
+
+	"github.com/soypat/sdf/internal/d2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// segment2 is a piece of a closed outline.
+type segment2 interface {
+	// dist2 returns the squared distance from p to the segment.
+	dist2(p r2.Vec) float64
+	// winding returns the signed number of times the segment crosses the
+	// horizontal ray from p towards positive X, positive when crossing
+	// upwards. Crossings at segment ends are counted on the lower end only
+	// so closed outlines contribute their winding number around p.
+	winding(p r2.Vec) int
+	// bounds returns a box containing the segment.
+	bounds() d2.Box
+}
+
+// outline is an SDF2 bounded by closed chains of segments. The inside
+// is found with the fill rule, the nonzero winding rule by default.
+type outline struct {
+	segments []segment2
+	rule     FillRule
+	bb       r2.Box
+}
+
+func newOutline(segments []segment2) *outline {
+	bb := segments[0].bounds()
+	for _, seg := range segments[1:] {
+		bb = bb.Extend(seg.bounds())
+	}
+	return &outline{segments: segments, bb: r2.Box(bb)}
+}
+
+// Evaluate returns the minimum distance to the outline.
+func (s *outline) Evaluate(p r2.Vec) float64 {
+	dist2, winding := s.dist2Winding(p)
+	if s.rule.inside(winding) {
+		return -math.Sqrt(dist2)
+	}
+	return math.Sqrt(dist2)
+}
+
+// dist2Winding returns the squared distance from p to the outline
+// and the winding number of the outline around p.
+func (s *outline) dist2Winding(p r2.Vec) (dist2 float64, winding int) {
+	dist2 = math.Inf(1)
+	for _, seg := range s.segments {
+		dist2 = math.Min(dist2, seg.dist2(p))
+		winding += seg.winding(p)
+	}
+	return dist2, winding
+}
+
+// Bounds returns the bounding box of the outline.
+func (s *outline) Bounds() r2.Box {
+	return s.bb
+}
+
+// bezier2 is a quadratic Bézier curve segment. Straight
+// segments are flagged as such and ignore the control point.
+type bezier2 struct {
+	p0, p1, p2 r2.Vec
+	line       bool
+}
+
+// at returns the point of the curve at parameter t.
+func (b bezier2) at(t float64) r2.Vec {
+	if b.line {
+		return r2.Add(b.p0, r2.Scale(t, r2.Sub(b.p2, b.p0)))
+	}
+	mt := 1 - t
+	return r2.Add(r2.Add(r2.Scale(mt*mt, b.p0), r2.Scale(2*mt*t, b.p1)), r2.Scale(t*t, b.p2))
+}
+
+func (b bezier2) dist2(p r2.Vec) float64 {
+	if b.line {
+		ab := r2.Sub(b.p2, b.p0)
+		t := clamp(r2.Dot(r2.Sub(p, b.p0), ab)/r2.Dot(ab, ab), 0, 1)
+		return r2.Norm2(r2.Sub(p, b.at(t)))
+	}
+	// The curve is p0 + 2t*a + t²*c. Stationary points of the squared
+	// distance to p are roots of a cubic polynomial in t.
+	a := r2.Sub(b.p1, b.p0)
+	c := r2.Add(r2.Sub(b.p0, r2.Scale(2, b.p1)), b.p2)
+	d := r2.Sub(b.p0, p)
+	best := math.Min(r2.Norm2(d), r2.Norm2(r2.Sub(b.p2, p)))
+	var roots [3]float64
+	n := solveCubic(roots[:], r2.Dot(c, c), 3*r2.Dot(a, c), 2*r2.Dot(a, a)+r2.Dot(d, c), r2.Dot(d, a))
+	for _, t := range roots[:n] {
+		if t > 0 && t < 1 {
+			best = math.Min(best, r2.Norm2(r2.Sub(p, b.at(t))))
+		}
+	}
+	return best
+}
+
+func (b bezier2) winding(p r2.Vec) int {
+	if b.line {
+		return lineWinding(b.p0, b.p2, p, func(t float64) float64 { return b.at(t).X })
+	}
+	if bb := b.bounds(); p.Y < bb.Min.Y || p.Y > bb.Max.Y || p.X > bb.Max.X {
+		return 0 // ray can't cross the curve.
+	}
+	// Split the curve at its vertical extremum into monotonic parts.
+	var splits []float64
+	if den := b.p0.Y - 2*b.p1.Y + b.p2.Y; den != 0 {
+		if t := (b.p0.Y - b.p1.Y) / den; t > 0 && t < 1 {
+			splits = []float64{t}
+		}
+	}
+	return curveWinding(b.at, splits, p)
+}
+
+func (b bezier2) bounds() d2.Box {
+	bb := d2.Box{Min: b.p0, Max: b.p0}.Include(b.p2)
+	if !b.line {
+		bb = bb.Include(b.p1)
+	}
+	return bb
+}
+
+// bezier3 is a cubic Bézier curve segment.
+type bezier3 struct {
+	p0, p1, p2, p3 r2.Vec
+}
+
+// at returns the point of the curve at parameter t.
+func (b bezier3) at(t float64) r2.Vec {
+	mt := 1 - t
+	return r2.Add(
+		r2.Add(r2.Scale(mt*mt*mt, b.p0), r2.Scale(3*mt*mt*t, b.p1)),
+		r2.Add(r2.Scale(3*mt*t*t, b.p2), r2.Scale(t*t*t, b.p3)),
+	)
+}
+
+// derivatives returns the first and second derivatives of the curve at t.
+func (b bezier3) derivatives(t float64) (first, second r2.Vec) {
+	mt := 1 - t
+	a, c, e := r2.Sub(b.p1, b.p0), r2.Sub(b.p2, b.p1), r2.Sub(b.p3, b.p2)
+	first = r2.Scale(3, r2.Add(r2.Add(r2.Scale(mt*mt, a), r2.Scale(2*mt*t, c)), r2.Scale(t*t, e)))
+	second = r2.Scale(6, r2.Add(r2.Scale(mt, r2.Sub(c, a)), r2.Scale(t, r2.Sub(e, c))))
+	return first, second
+}
+
+// dist2 returns the squared distance from p to the curve. The closest
+// point of the curve is found to machine precision by refining the local
+// minima of the distance at evenly spaced samples with Newton's method.
+func (b bezier3) dist2(p r2.Vec) float64 {
+	const samples = 16
+	var d [samples + 1]float64
+	for i := range d {
+		d[i] = r2.Norm2(r2.Sub(b.at(float64(i)/samples), p))
+	}
+	best := math.Min(d[0], d[samples])
+	for i := range d {
+		if (i > 0 && d[i] > d[i-1]) || (i < samples && d[i] > d[i+1]) {
+			continue
+		}
+		// Newton iterations on the derivative of the squared
+		// distance with steps limited to the sample spacing.
+		t := float64(i) / samples
+		for j := 0; j < 16; j++ {
+			v := r2.Sub(b.at(t), p)
+			first, second := b.derivatives(t)
+			f := r2.Dot(v, first)
+			df := r2.Dot(first, first) + r2.Dot(v, second)
+			if df <= 0 {
+				break
+			}
+			step := clamp(f/df, -1./samples, 1./samples)
+			t = clamp(t-step, 0, 1)
+			if math.Abs(step) < 1e-15 {
+				break
+			}
+		}
+		best = math.Min(best, r2.Norm2(r2.Sub(b.at(t), p)))
+	}
+	return best
+}
+
+func (b bezier3) winding(p r2.Vec) int {
+	if bb := b.bounds(); p.Y < bb.Min.Y || p.Y > bb.Max.Y || p.X > bb.Max.X {
+		return 0 // ray can't cross the curve.
+	}
+	// Split the curve at its vertical extrema into monotonic parts. The
+	// derivative of Y is proportional to a t² + c t + e.
+	a := b.p3.Y - 3*b.p2.Y + 3*b.p1.Y - b.p0.Y
+	c := 2 * (b.p2.Y - 2*b.p1.Y + b.p0.Y)
+	e := b.p1.Y - b.p0.Y
+	var roots [3]float64
+	n := solveCubic(roots[:], 0, a, c, e)
+	splits := make([]float64, 0, 2)
+	for _, t := range roots[:n] {
+		if t > 0 && t < 1 {
+			splits = append(splits, t)
+		}
+	}
+	if len(splits) == 2 && splits[0] > splits[1] {
+		splits[0], splits[1] = splits[1], splits[0]
+	}
+	return curveWinding(b.at, splits, p)
+}
+
+func (b bezier3) bounds() d2.Box {
+	return d2.Box{Min: b.p0, Max: b.p0}.Include(b.p1).Include(b.p2).Include(b.p3)
+}
+
+// arc2 is a circular arc segment from p0 to p1 about center.
+type arc2 struct {
+	p0, p1, center r2.Vec
+	radius         float64
+	// start is the angle of p0 about the center and sweep
+	// the signed angle of the arc, positive counterclockwise.
+	start, sweep float64
+}
+
+// newArc2 returns the arc from a to b about center c.
+func newArc2(a, b, c r2.Vec, ccw bool) arc2 {
+	start := math.Atan2(a.Y-c.Y, a.X-c.X)
+	sweep := math.Atan2(b.Y-c.Y, b.X-c.X) - start
+	if ccw {
+		sweep = wrapAngle(sweep)
+	} else {
+		sweep = -wrapAngle(-sweep)
+	}
+	return arc2{p0: a, p1: b, center: c, radius: r2.Norm(r2.Sub(a, c)), start: start, sweep: sweep}
+}
+
+// at returns the point of the arc at parameter t.
+func (a arc2) at(t float64) r2.Vec {
+	switch t {
+	case 0:
+		return a.p0
+	case 1:
+		return a.p1
+	}
+	sin, cos := math.Sincos(a.start + t*a.sweep)
+	return r2.Add(a.center, r2.Vec{X: a.radius * cos, Y: a.radius * sin})
+}
+
+// contains reports whether the ray from the center at angle theta crosses the arc.
+func (a arc2) contains(theta float64) bool {
+	rel := theta - a.start
+	if a.sweep < 0 {
+		rel = -rel
+	}
+	return wrapAngle(rel) <= math.Abs(a.sweep)
+}
+
+func (a arc2) dist2(p r2.Vec) float64 {
+	cp := r2.Sub(p, a.center)
+	if a.contains(math.Atan2(cp.Y, cp.X)) {
+		d := r2.Norm(cp) - a.radius
+		return d * d
+	}
+	return math.Min(r2.Norm2(r2.Sub(p, a.p0)), r2.Norm2(r2.Sub(p, a.p1)))
+}
+
+func (a arc2) winding(p r2.Vec) int {
+	if math.Abs(p.Y-a.center.Y) > a.radius || p.X > a.center.X+a.radius {
+		return 0 // ray can't cross the arc.
+	}
+	// Split the arc at its top and bottom into monotonic parts.
+	return curveWinding(a.at, a.extrema(math.Pi/2, math.Pi), p)
+}
+
+func (a arc2) bounds() d2.Box {
+	bb := d2.Box{Min: a.p0, Max: a.p0}.Include(a.p1)
+	for _, t := range a.extrema(0, math.Pi/2) {
+		bb = bb.Include(a.at(t))
+	}
+	return bb
+}
+
+// extrema returns the increasing parameters inside the arc
+// at the angles offset + k*step for integer k.
+func (a arc2) extrema(offset, step float64) []float64 {
+	var ts []float64
+	for k := -8; k <= 8; k++ {
+		if t := (offset + float64(k)*step - a.start) / a.sweep; t > 0 && t < 1 {
+			ts = append(ts, t)
+		}
+	}
+	if a.sweep < 0 {
+		for i, j := 0, len(ts)-1; i < j; i, j = i+1, j-1 {
+			ts[i], ts[j] = ts[j], ts[i]
+		}
+	}
+	return ts
+}
+
+// curveWinding returns the winding contribution of a curve split at the
+// increasing parameters in splits into parts which are monotonic in Y.
+func curveWinding(at func(t float64) r2.Vec, splits []float64, p r2.Vec) int {
+	w := 0
+	t0, start := 0., at(0)
+	for i := 0; i <= len(splits); i++ {
+		t1 := 1.
+		if i < len(splits) {
+			t1 = splits[i]
+		}
+		end := at(t1)
+		lo, hi := t0, t1
+		w += lineWinding(start, end, p, func(float64) float64 {
+			// Find the curve parameter at the height of p by bisection
+			// since the part is monotonic between start and end.
+			up := end.Y > start.Y
+			for j := 0; j < 64; j++ {
+				mid := (lo + hi) / 2
+				if (at(mid).Y < p.Y) == up {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			return at((lo + hi) / 2).X
+		})
+		t0, start = t1, end
+	}
+	return w
+}
+
+// lineWinding returns the winding contribution of a part of a curve from
+// start to end monotonic in Y. xAt returns the X coordinate of the part
+// at the height of p and is only called when the part crosses it.
+func lineWinding(start, end, p r2.Vec, xAt func(t float64) float64) int {
+	switch {
+	case start.Y <= p.Y && end.Y > p.Y:
+		// Upwards crossing.
+		if xAt((p.Y-start.Y)/(end.Y-start.Y)) > p.X {
+			return 1
+		}
+	case end.Y <= p.Y && start.Y > p.Y:
+		// Downwards crossing.
+		if xAt((p.Y-start.Y)/(end.Y-start.Y)) > p.X {
+			return -1
+		}
+	}
+	return 0
+}
+
+// flattenCubic appends points approximating the curve to dst, excluding
+// its first point, so that the polyline deviates from the curve by at
+// most tol.
+func flattenCubic(dst []r2.Vec, b bezier3, tol float64, depth int) []r2.Vec {
+	// The curve lies within the convex hull of the control points so it
+	// is flat when the control points are close to the chord.
+	if depth == 0 || (distToSegment(b.p1, b.p0, b.p3) <= tol && distToSegment(b.p2, b.p0, b.p3) <= tol) {
+		return append(dst, b.p3)
+	}
+	// Split in halves with de Casteljau's algorithm.
+	ab, bc, cd := midpoint(b.p0, b.p1), midpoint(b.p1, b.p2), midpoint(b.p2, b.p3)
+	abc, bcd := midpoint(ab, bc), midpoint(bc, cd)
+	mid := midpoint(abc, bcd)
+	dst = flattenCubic(dst, bezier3{p0: b.p0, p1: ab, p2: abc, p3: mid}, tol, depth-1)
+	return flattenCubic(dst, bezier3{p0: mid, p1: bcd, p2: cd, p3: b.p3}, tol, depth-1)
+}
+
+// flattenArc appends points approximating the arc to dst, excluding its
+// first point, so that the polyline deviates from the arc by at most tol.
+func flattenArc(dst []r2.Vec, a arc2, tol float64) []r2.Vec {
+	// The sagitta of a chord spanning angle step is r*(1-cos(step/2)).
+	step := 2 * math.Acos(math.Max(-1, 1-tol/a.radius))
+	n := int(math.Ceil(math.Abs(a.sweep) / step))
+	if n < 1 {
+		n = 1
+	}
+	for i := 1; i <= n; i++ {
+		dst = append(dst, a.at(float64(i)/float64(n)))
+	}
+	return dst
+}
+
+func midpoint(a, b r2.Vec) r2.Vec {
+	return r2.Scale(0.5, r2.Add(a, b))
+}
+
+// distToSegment returns the distance from p to the line segment ab.
+func distToSegment(p, a, b r2.Vec) float64 {
+	return math.Sqrt(bezier2{p0: a, p2: b, line: true}.dist2(p))
+}
+
+// solveCubic writes the real roots of a t³ + b t² + c t + d to roots and
+// returns how many there are. Lower order polynomials are solved when the
+// leading coefficients vanish.
+func solveCubic(roots []float64, a, b, c, d float64) int {
+	if math.Abs(a) <= 1e-12*(math.Abs(b)+math.Abs(c)+math.Abs(d)) {
+		// Quadratic.
+		if math.Abs(b) <= 1e-12*(math.Abs(c)+math.Abs(d)) {
+			if c == 0 {
+				return 0
+			}
+			roots[0] = -d / c
+			return 1
+		}
+		disc := c*c - 4*b*d
+		if disc < 0 {
+			return 0
+		}
+		sq := math.Sqrt(disc)
+		roots[0] = (-c + sq) / (2 * b)
+		roots[1] = (-c - sq) / (2 * b)
+		return 2
+	}
+	// Depressed cubic x³ + px + q with t = x - b/3a.
+	b, c, d = b/a, c/a, d/a
+	offset := b / 3
+	p := c - b*b/3
+	q := 2*b*b*b/27 - b*c/3 + d
+	disc := q*q/4 + p*p*p/27
+	if disc > 0 {
+		sq := math.Sqrt(disc)
+		roots[0] = math.Cbrt(-q/2+sq) + math.Cbrt(-q/2-sq) - offset
+		return 1
+	}
+	if p == 0 {
+		roots[0] = -offset
+		return 1
+	}
+	// Three real roots by the trigonometric method.
+	m := 2 * math.Sqrt(-p/3)
+	theta := math.Acos(clamp(3*q/(p*m), -1, 1)) / 3
+	for k := range roots[:3] {
+		roots[k] = m*math.Cos(theta-2*math.Pi*float64(k)/3) - offset
+	}
+	return 3
+}
+
+func clamp(x, a, b float64) float64 {
+	return math.Max(a, math.Min(b, x))
+}
+
+// wrapAngle returns the angle in [0, 2π).
+func wrapAngle(theta float64) float64 {
+	theta = math.Mod(theta, 2*math.Pi)
+	if theta < 0 {
+		theta += 2 * math.Pi
+	}
+	return theta
+}
@@ -0,0 +1,207 @@
+package must2
+
+import (
+	"image"
+	"math"
+
+	"github.com/soypat/sdf/internal/d2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// ImageMode selects which pixels of an image are inside a shape.
+type ImageMode int
+
+const (
+	// ImageDark selects pixels with a luminance below the threshold.
+	// Transparent pixels are blended over a white background.
+	ImageDark ImageMode = iota
+	// ImageLight selects pixels with a luminance above the threshold.
+	// Transparent pixels are blended over a black background.
+	ImageLight
+	// ImageAlpha selects pixels with an opacity above the threshold.
+	ImageAlpha
+)
+
+// imageSDF is the 2d signed distance object of the pixels of an image.
+type imageSDF struct {
+	// signed distance at pixel centers, row major starting at the
+	// bottom row. The grid is padded with a pixel of outside space
+	// on every side.
+	dist          []float64
+	width, height int
+	pixelSize     float64
+	// position of the center of the first grid pixel.
+	origin r2.Vec
+	bb     r2.Box
+}
+
+// Image returns the SDF2 of the pixels of img selected by mode with a
+// threshold between 0 and 1. Pixels are squares of side pixelSize and the
+// image is centered at the origin with the Y axis pointing up. Distances
+// are found with an exact euclidean distance transform of the pixel centers
+// and are sampled bilinearly between pixels.
+func Image(img image.Image, pixelSize float64, mode ImageMode, threshold float64) *imageSDF {
+	if pixelSize <= 0 {
+		panic("pixel size must be positive")
+	}
+	if mode < ImageDark || mode > ImageAlpha {
+		panic("invalid image mode")
+	}
+	rect := img.Bounds()
+	if rect.Empty() {
+		panic("empty image")
+	}
+	// Pad the grid with a pixel on each side so the
+	// outline of pixels on the image edges is found.
+	w, h := rect.Dx()+2, rect.Dy()+2
+	inside := make([]bool, w*h)
+	found := false
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if imagePixelInside(img, x, y, mode, threshold) {
+				// Flip rows so that Y points up.
+				inside[(rect.Max.Y-y)*w+x-rect.Min.X+1] = true
+				found = true
+			}
+		}
+	}
+	if !found {
+		panic("no image pixels inside shape")
+	}
+	// Squared distances in pixels to the nearest
+	// pixel center of the opposite kind.
+	toInside := edt2(inside, w, h, true)
+	toOutside := edt2(inside, w, h, false)
+	s := imageSDF{
+		dist:      make([]float64, w*h),
+		width:     w,
+		height:    h,
+		pixelSize: pixelSize,
+	}
+	// The outline lies halfway between pixel centers.
+	for i := range s.dist {
+		if inside[i] {
+			s.dist[i] = -(math.Sqrt(toOutside[i]) - 0.5) * pixelSize
+		} else {
+			s.dist[i] = (math.Sqrt(toInside[i]) - 0.5) * pixelSize
+		}
+	}
+	size := r2.Vec{X: float64(rect.Dx()) * pixelSize, Y: float64(rect.Dy()) * pixelSize}
+	s.bb = r2.Box{Min: r2.Scale(-0.5, size), Max: r2.Scale(0.5, size)}
+	s.origin = r2.Sub(s.bb.Min, d2.Elem(pixelSize/2))
+	return &s
+}
+
+// Evaluate returns the minimum distance to the image pixels.
+func (s *imageSDF) Evaluate(p r2.Vec) float64 {
+	// Continuous grid coordinates of p.
+	grid := d2.Box{Max: r2.Vec{X: float64(s.width - 1), Y: float64(s.height - 1)}}
+	u := r2.Scale(1/s.pixelSize, r2.Sub(p, s.origin))
+	// Outside the grid the distance to the grid is added
+	// to the distance sampled at the grid boundary.
+	outside := math.Sqrt(grid.Dist2(u)) * s.pixelSize
+	u = d2.Clamp(u, grid.Min, grid.Max)
+	x0 := math.Min(math.Floor(u.X), float64(s.width-2))
+	y0 := math.Min(math.Floor(u.Y), float64(s.height-2))
+	fx, fy := u.X-x0, u.Y-y0
+	i := int(y0)*s.width + int(x0)
+	d00, d10 := s.dist[i], s.dist[i+1]
+	d01, d11 := s.dist[i+s.width], s.dist[i+s.width+1]
+	d := (d00*(1-fx)+d10*fx)*(1-fy) + (d01*(1-fx)+d11*fx)*fy
+	return d + outside
+}
+
+// Bounds returns the bounding box of the image.
+func (s *imageSDF) Bounds() r2.Box {
+	return s.bb
+}
+
+// imagePixelInside reports whether pixel (x,y) of img is selected by mode.
+func imagePixelInside(img image.Image, x, y int, mode ImageMode, threshold float64) bool {
+	r, g, b, a := img.At(x, y).RGBA()
+	if mode == ImageAlpha {
+		return float64(a)/0xffff > threshold
+	}
+	// Colors are alpha premultiplied so they are blended over black.
+	lum := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+	if mode == ImageDark {
+		lum += 1 - float64(a)/0xffff
+		return lum < threshold
+	}
+	return lum > threshold
+}
+
+// edt2 returns the squared euclidean distance transform of a w by h grid,
+// that is the squared distance from every cell to the nearest cell for
+// which grid equals target. It uses the separable algorithm of
+// Felzenszwalb and Huttenlocher, "Distance Transforms of Sampled Functions".
+func edt2(grid []bool, w, h int, target bool) []float64 {
+	// Larger than any squared distance in the grid.
+	inf := float64(w*w + h*h + 1)
+	dt := make([]float64, w*h)
+	for i, v := range grid {
+		if v != target {
+			dt[i] = inf
+		}
+	}
+	n := w
+	if h > n {
+		n = h
+	}
+	f := make([]float64, n)
+	d := make([]float64, n)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+	// Columns then rows.
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			f[y] = dt[y*w+x]
+		}
+		edt1(f[:h], d[:h], v, z)
+		for y := 0; y < h; y++ {
+			dt[y*w+x] = d[y]
+		}
+	}
+	for y := 0; y < h; y++ {
+		row := dt[y*w : (y+1)*w]
+		copy(f, row)
+		edt1(f[:w], d[:w], v, z)
+		copy(row, d[:w])
+	}
+	return dt
+}
+
+// edt1 writes the one dimensional squared distance transform of the sampled
+// function f to d by finding the lower envelope of the parabolas rooted at
+// each sample. v and z are scratch buffers of at least len(f) and len(f)+1.
+func edt1(f, d []float64, v []int, z []float64) {
+	k := 0
+	v[0] = 0
+	z[0] = math.Inf(-1)
+	z[1] = math.Inf(1)
+	for q := 1; q < len(f); q++ {
+		s := parabolaIntersect(f, q, v[k])
+		for s <= z[k] {
+			k--
+			s = parabolaIntersect(f, q, v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+	k = 0
+	for q := range f {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		p := v[k]
+		d[q] = float64((q-p)*(q-p)) + f[p]
+	}
+}
+
+// parabolaIntersect returns the abscissa of the intersection of
+// the parabolas rooted at samples q and p of f.
+func parabolaIntersect(f []float64, q, p int) float64 {
+	return ((f[q] + float64(q*q)) - (f[p] + float64(p*p))) / float64(2*(q-p))
+}
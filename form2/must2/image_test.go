@@ -0,0 +1,58 @@
+package must2_test
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf/form2/must2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestImage(t *testing.T) {
+	const (
+		size      = 64
+		radius    = 20.
+		pixelSize = 0.5
+		// Rasterizing the disk displaces its outline by up to a pixel.
+		tol = 2 * pixelSize
+	)
+	// Dark disk on white background with transparent corners.
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			dx, dy := float64(x)+0.5-size/2, float64(y)+0.5-size/2
+			if math.Hypot(dx, dy) < radius {
+				c = color.NRGBA{A: 255}
+			} else if x == 0 && y == 0 {
+				c = color.NRGBA{}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	for _, mode := range []must2.ImageMode{must2.ImageDark, must2.ImageLight, must2.ImageAlpha} {
+		s := must2.Image(img, pixelSize, mode, 0.5)
+		bb := s.Bounds()
+		if bb.Max.X != size*pixelSize/2 || bb.Min.Y != -size*pixelSize/2 {
+			t.Errorf("mode %d: got bounds %v", mode, bb)
+		}
+		for _, p := range []r2.Vec{{}, {X: 3}, {X: 8, Y: -6}, {Y: 12}, {X: 15, Y: 15}, {X: -40, Y: 3}} {
+			// Distance to the disk in physical units.
+			want := math.Hypot(p.X, p.Y) - radius*pixelSize
+			switch mode {
+			case must2.ImageLight:
+				// Image minus the disk.
+				want = math.Max(-want, math.Max(math.Abs(p.X), math.Abs(p.Y))-size*pixelSize/2)
+			case must2.ImageAlpha:
+				// Whole image but transparent corner pixel.
+				want = math.Max(math.Abs(p.X), math.Abs(p.Y)) - size*pixelSize/2
+			}
+			got := s.Evaluate(p)
+			if math.Abs(got-want) > tol {
+				t.Errorf("mode %d: distance at %v got %g, want %g", mode, p, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package must2
+
+import (
+	"github.com/soypat/sdf"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// FillRule decides which regions enclosed by the contours of a path are
+// inside the shape.
+type FillRule int
+
+const (
+	// FillNonZero fills regions the contours wind around a nonzero number
+	// of times so overlapping contours of the same direction are merged.
+	FillNonZero FillRule = iota
+	// FillEvenOdd fills regions enclosed by an odd number of contours so
+	// nested contours are holes regardless of their direction.
+	FillEvenOdd
+)
+
+// inside reports whether a point with the winding number is inside.
+func (r FillRule) inside(winding int) bool {
+	if r == FillEvenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
+
+// PathBuilder stores closed contours made of lines, circular arcs and
+// Bézier curves, such as the outlines of SVG paths and DXF drawings.
+type PathBuilder struct {
+	segments []segment2
+	start    r2.Vec // start of the current contour
+	current  r2.Vec // end of the last segment
+	drawing  bool   // a contour has been started
+}
+
+// NewPath returns an empty path.
+func NewPath() *PathBuilder {
+	return &PathBuilder{}
+}
+
+// MoveTo closes the current contour and starts a new one at p.
+func (b *PathBuilder) MoveTo(p r2.Vec) {
+	b.Close()
+	b.start, b.current = p, p
+	b.drawing = true
+}
+
+// LineTo adds a line segment from the current point to p.
+func (b *PathBuilder) LineTo(p r2.Vec) {
+	b.add(bezier2{p0: b.current, p2: p, line: true}, p)
+}
+
+// QuadTo adds a quadratic Bézier curve with control point c
+// from the current point to p.
+func (b *PathBuilder) QuadTo(c, p r2.Vec) {
+	b.add(bezier2{p0: b.current, p1: c, p2: p}, p)
+}
+
+// CubicTo adds a cubic Bézier curve with control points c1 and c2
+// from the current point to p.
+func (b *PathBuilder) CubicTo(c1, c2, p r2.Vec) {
+	b.add(bezier3{p0: b.current, p1: c1, p2: c2, p3: p}, p)
+}
+
+// ArcTo adds a circular arc about center from the current point to p,
+// counterclockwise if ccw is true. The current point and p should be
+// at the same distance from the center.
+func (b *PathBuilder) ArcTo(center, p r2.Vec, ccw bool) {
+	if b.current == p {
+		return // use two arcs for full circles.
+	}
+	b.add(newArc2(b.current, p, center, ccw), p)
+}
+
+// Current returns the end point of the last segment.
+func (b *PathBuilder) Current() r2.Vec {
+	return b.current
+}
+
+// Close adds a line segment from the current point to the start of
+// the current contour if they are not the same point.
+func (b *PathBuilder) Close() {
+	if b.drawing && b.current != b.start {
+		b.LineTo(b.start)
+	}
+	b.current = b.start
+}
+
+// Empty reports whether the path has no segments.
+func (b *PathBuilder) Empty() bool {
+	return len(b.segments) == 0
+}
+
+// Build closes the current contour and returns the SDF2 of the path with
+// exact distances to its segments. The inside is found with the fill rule.
+func (b *PathBuilder) Build(rule FillRule) sdf.SDF2 {
+	if rule != FillNonZero && rule != FillEvenOdd {
+		panic("invalid fill rule")
+	}
+	b.Close()
+	if len(b.segments) == 0 {
+		panic("path has no segments")
+	}
+	s := newOutline(b.segments)
+	s.rule = rule
+	return s
+}
+
+func (b *PathBuilder) add(seg segment2, end r2.Vec) {
+	if !b.drawing {
+		panic("path segment added before MoveTo")
+	}
+	if b.current != end || seg.bounds().Size() != (r2.Vec{}) {
+		b.segments = append(b.segments, seg)
+	}
+	b.current = end
+}
@@ -115,27 +115,33 @@ func (s *polygon) Bounds() r2.Box {
 
 // PolygonBuilder stores a set of 2d polygon vertices.
 type PolygonBuilder struct {
-	closed  bool            // is the polygon closed or open?
-	reverse bool            // return the vertices in reverse order
-	vlist   []polygonVertex // list of polygon vertices
+	closed   bool            // is the polygon closed or open?
+	reverse  bool            // return the vertices in reverse order
+	flatness float64         // maximum deviation of flattened curves
+	vlist    []polygonVertex // list of polygon vertices
 }
 
 // polygonVertex is a polygon vertex.
 type polygonVertex struct {
-	relative bool    // vertex position is relative to previous vertex
-	vtype    pvType  // type of polygon vertex
-	vertex   r2.Vec  // vertex coordinates
-	facets   int     // number of polygon facets to create when smoothing
-	radius   float64 // radius of smoothing (0 == none)
+	relative bool      // vertex position is relative to previous vertex
+	vtype    pvType    // type of polygon vertex
+	vertex   r2.Vec    // vertex coordinates
+	facets   int       // number of polygon facets to create when smoothing (0 == exact arc)
+	radius   float64   // radius of smoothing (0 == none), positive for counterclockwise exact arcs
+	control  [2]r2.Vec // Bézier control points or arc center of the segment ending at the vertex
 }
 
 // pvType is the type of a polygon vertex.
 type pvType int
 
 const (
-	pvNormal pvType = iota // normal vertex
-	pvSmooth               // smooth the vertex
-	pvArc                  // replace the line segment with an arc
+	pvNormal     pvType = iota // normal vertex
+	pvSmooth                   // smooth the vertex
+	pvArc                      // replace the line segment with an arc
+	pvQuadratic                // replace the line segment with a quadratic Bézier curve
+	pvCubic                    // replace the line segment with a cubic Bézier curve
+	pvCatmullRom               // replace the line segment with a Catmull-Rom spline segment
+	pvCircle                   // the line segment is an exact circular arc
 )
 
 // Operations on Polygon Vertices
@@ -152,9 +158,10 @@ func (v *polygonVertex) Polar() *polygonVertex {
 	return v
 }
 
-// Smooth marks the polygon vertex for smoothing.
+// Smooth marks the polygon vertex for smoothing. The vertex is replaced
+// by an exact circular arc if facets is 0.
 func (v *polygonVertex) Smooth(radius float64, facets int) *polygonVertex {
-	if radius != 0 && facets != 0 {
+	if radius != 0 {
 		v.radius = radius
 		v.facets = facets
 		v.vtype = pvSmooth
@@ -175,9 +182,10 @@ func (v *polygonVertex) Chamfer(size float64) *polygonVertex {
 	return v
 }
 
-// Arc replaces a line segment with a circular arc.
+// Arc replaces a line segment with a circular arc. The arc is
+// exact if facets is 0, otherwise it is made of facets line segments.
 func (v *polygonVertex) Arc(radius float64, facets int) *polygonVertex {
-	if radius != 0 && facets != 0 {
+	if radius != 0 {
 		v.radius = radius
 		v.facets = facets
 		v.vtype = pvArc
@@ -185,6 +193,38 @@ func (v *polygonVertex) Arc(radius float64, facets int) *polygonVertex {
 	return v
 }
 
+// Quadratic replaces the line segment ending at the vertex with a quadratic
+// Bézier curve with control point c. The control point is relative to the
+// previous vertex if the vertex is.
+func (v *polygonVertex) Quadratic(c r2.Vec) *polygonVertex {
+	v.control[0] = c
+	v.vtype = pvQuadratic
+	return v
+}
+
+// Cubic replaces the line segment ending at the vertex with a cubic Bézier
+// curve with control points c1 and c2. The control points are relative to
+// the previous vertex if the vertex is.
+func (v *polygonVertex) Cubic(c1, c2 r2.Vec) *polygonVertex {
+	v.control = [2]r2.Vec{c1, c2}
+	v.vtype = pvCubic
+	return v
+}
+
+// CatmullRom replaces the line segment ending at the vertex with a
+// Catmull-Rom spline segment. The tangents of the curve at its ends are
+// parallel to the lines joining the neighbouring vertices so consecutive
+// CatmullRom vertices form a smooth curve through them.
+func (v *polygonVertex) CatmullRom() *polygonVertex {
+	v.vtype = pvCatmullRom
+	return v
+}
+
+// curved reports whether the segment ending at the vertex is a curve.
+func (v *polygonVertex) curved() bool {
+	return v.vtype == pvQuadratic || v.vtype == pvCubic || v.vtype == pvCatmullRom || v.vtype == pvCircle
+}
+
 // nextVertex returns the next vertex in the polygon.
 func (p *PolygonBuilder) nextVertex(i int) *polygonVertex {
 	if i == len(p.vlist)-1 {
@@ -240,6 +280,13 @@ func (p *PolygonBuilder) arcVertex(i int) bool {
 	dCenter := math.Sqrt((radius * radius) - (dMid * dMid))
 	// center of arc
 	c := r2.Add(mid, r2.Scale(dCenter, n))
+	if v.facets == 0 {
+		// keep the exact arc, positive radius for counterclockwise arcs
+		v.vtype = pvCircle
+		v.control[0] = c
+		v.radius = -side * radius
+		return true
+	}
 	// work out the angle
 	ac := r2.Unit(r2.Sub(a, c))
 	bc := r2.Unit(r2.Sub(b, c))
@@ -289,6 +336,10 @@ func (p *PolygonBuilder) smoothVertex(i int) bool {
 		// can't smooth the endpoints of an open polygon
 		return false
 	}
+	if vn.curved() {
+		// can't smooth the start of a curve
+		return false
+	}
 	// work out the angle
 	v0 := r2.Unit(r2.Sub(vp.vertex, v.vertex))
 	v1 := r2.Unit(r2.Sub(vn.vertex, v.vertex))
@@ -306,6 +357,16 @@ func (p *PolygonBuilder) smoothVertex(i int) bool {
 	// center of circle
 	vc := r2.Unit(r2.Add(v0, v1))
 	c := r2.Add(v.vertex, r2.Scale(d2, vc))
+	if v.facets == 0 {
+		// replace the vertex with an exact arc between the tangent points
+		p1 := r2.Add(v.vertex, r2.Scale(d1, v1))
+		points := []polygonVertex{
+			{vertex: p0},
+			{vertex: p1, vtype: pvCircle, control: [2]r2.Vec{c}, radius: Sign(r2.Cross(v1, v0)) * v.radius},
+		}
+		p.vlist = append(p.vlist[:i], append(points, p.vlist[i+1:]...)...)
+		return true
+	}
 	// rotation angle
 	dtheta := Sign(r2.Cross(v1, v0)) * (math.Pi - theta) / float64(v.facets)
 	// rotation matrix
@@ -336,6 +397,33 @@ func (p *PolygonBuilder) smoothVertices() {
 	}
 }
 
+// createSplines converts Catmull-Rom spline segments to cubic Bézier curves.
+func (p *PolygonBuilder) createSplines() {
+	for i := range p.vlist {
+		v := &p.vlist[i]
+		if v.vtype != pvCatmullRom {
+			continue
+		}
+		pv := p.prevVertex(i)
+		if pv == nil {
+			// no segment ends at the first vertex of an open polygon
+			v.vtype = pvNormal
+			continue
+		}
+		// Use the segment ends as their missing neighbours.
+		before, after := pv.vertex, v.vertex
+		if ppv := p.prevVertex((i + len(p.vlist) - 1) % len(p.vlist)); ppv != nil {
+			before = ppv.vertex
+		}
+		if nv := p.nextVertex(i); nv != nil {
+			after = nv.vertex
+		}
+		v.control[0] = r2.Add(pv.vertex, r2.Scale(1./6, r2.Sub(v.vertex, before)))
+		v.control[1] = r2.Sub(v.vertex, r2.Scale(1./6, r2.Sub(after, pv.vertex)))
+		v.vtype = pvCubic
+	}
+}
+
 // relToAbs converts relative vertices to absolute vertices.
 func (p *PolygonBuilder) relToAbs() error {
 	for i := range p.vlist {
@@ -346,6 +434,10 @@ func (p *PolygonBuilder) relToAbs() error {
 				return fmt.Errorf("relative vertex needs an absolute reference")
 			}
 			v.vertex = r2.Add(v.vertex, pv.vertex)
+			if v.curved() {
+				v.control[0] = r2.Add(v.control[0], pv.vertex)
+				v.control[1] = r2.Add(v.control[1], pv.vertex)
+			}
 			v.relative = false
 		}
 	}
@@ -355,9 +447,36 @@ func (p *PolygonBuilder) relToAbs() error {
 func (p *PolygonBuilder) fixups() {
 	p.relToAbs()
 	p.createArcs()
+	p.createSplines()
 	p.smoothVertices()
 }
 
+// segment returns the curve or arc ending at the i-th
+// vertex after fixups. It is nil for straight segments.
+func (p *PolygonBuilder) segment(i int) segment2 {
+	v := &p.vlist[i]
+	pv := p.prevVertex(i)
+	if pv == nil {
+		return nil
+	}
+	switch v.vtype {
+	case pvQuadratic:
+		// Elevate the degree of the curve.
+		c := v.control[0]
+		return bezier3{
+			p0: pv.vertex,
+			p1: r2.Add(pv.vertex, r2.Scale(2./3, r2.Sub(c, pv.vertex))),
+			p2: r2.Add(v.vertex, r2.Scale(2./3, r2.Sub(c, v.vertex))),
+			p3: v.vertex,
+		}
+	case pvCubic:
+		return bezier3{p0: pv.vertex, p1: v.control[0], p2: v.control[1], p3: v.vertex}
+	case pvCircle:
+		return newArc2(pv.vertex, v.vertex, v.control[0], v.radius > 0)
+	}
+	return nil
+}
+
 // Public API for polygons
 
 // Close closes the polygon.
@@ -370,6 +489,16 @@ func (p *PolygonBuilder) Closed() bool {
 	return p.closed
 }
 
+// Flatness sets the maximum distance between curves or exact arcs and the
+// line segments that approximate them in Vertices. Defaults to 1e-3 times
+// the largest dimension of the bounding box of the vertices and curves.
+func (p *PolygonBuilder) Flatness(tol float64) {
+	if tol <= 0 {
+		panic("flatness must be positive")
+	}
+	p.flatness = tol
+}
+
 // Reverse reverses the order the vertices are returned.
 func (p *PolygonBuilder) Reverse() {
 	p.reverse = true
@@ -412,20 +541,65 @@ func (p *PolygonBuilder) Vertices() []r2.Vec {
 		panic("nil vertex list. was PolygonBuilder initialized?")
 	}
 	p.fixups()
-	n := len(p.vlist)
-	v := make([]r2.Vec, n)
-	if p.reverse {
-		for i, pv := range p.vlist {
-			v[n-1-i] = pv.vertex
+	tol := p.flatness
+	if tol == 0 {
+		tol = 1e-3 * d2.Max(p.bounds().Size())
+	}
+	v := make([]r2.Vec, 0, len(p.vlist))
+	for i, pv := range p.vlist {
+		// The curve end is the vertex itself.
+		switch seg := p.segment(i).(type) {
+		case bezier3:
+			v = flattenCubic(v, seg, tol, 16)
+			v = v[:len(v)-1]
+		case arc2:
+			v = flattenArc(v, seg, tol)
+			v = v[:len(v)-1]
 		}
-	} else {
-		for i, pv := range p.vlist {
-			v[i] = pv.vertex
+		v = append(v, pv.vertex)
+	}
+	if p.reverse {
+		for i, j := 0, len(v)-1; i < j; i, j = i+1, j-1 {
+			v[i], v[j] = v[j], v[i]
 		}
 	}
 	return v
 }
 
+// Build returns the SDF2 of the polygon which is closed if it is open.
+// Unlike Polygon(p.Vertices()) curved segments and exact arcs are evaluated
+// exactly instead of being flattened to line segments.
+func (p *PolygonBuilder) Build() sdf.SDF2 {
+	if len(p.vlist) < 2 {
+		panic("number of vertices < 2")
+	}
+	p.fixups()
+	segments := make([]segment2, 0, len(p.vlist))
+	for i, v := range p.vlist {
+		if seg := p.segment(i); seg != nil {
+			segments = append(segments, seg)
+			continue
+		}
+		start := p.vlist[(i+len(p.vlist)-1)%len(p.vlist)].vertex
+		if start != v.vertex {
+			segments = append(segments, bezier2{p0: start, p2: v.vertex, line: true})
+		}
+	}
+	return newOutline(segments)
+}
+
+// bounds returns the bounding box of the vertices, curves and arcs.
+func (p *PolygonBuilder) bounds() d2.Box {
+	bb := d2.Box{Min: p.vlist[0].vertex, Max: p.vlist[0].vertex}
+	for i, v := range p.vlist {
+		bb = bb.Include(v.vertex)
+		if seg := p.segment(i); seg != nil {
+			bb = bb.Extend(seg.bounds())
+		}
+	}
+	return bb
+}
+
 // Nagon return the vertices of a N sided regular polygon.
 func Nagon(n int, radius float64) d2.Set {
 	if n < 3 {
@@ -0,0 +1,133 @@
+package must2_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/internal/d2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestPolygonCurves(t *testing.T) {
+	const flatness = 1e-4
+	newProfile := func() *must2.PolygonBuilder {
+		// Knob profile with a cubic top, quadratic side
+		// and a spline through the bottom vertices.
+		p := must2.NewPolygon()
+		p.Add(0, 0)
+		p.Add(4, 0)
+		p.Add(4, 3).Quadratic(r2.Vec{X: 5, Y: 1.5})
+		p.Add(0, 3).Cubic(r2.Vec{X: 3, Y: 5}, r2.Vec{X: 1, Y: 2})
+		p.Add(-1, 1.5).CatmullRom()
+		p.Add(0, 0).CatmullRom()
+		p.Close()
+		p.Flatness(flatness)
+		return p
+	}
+	exact := newProfile().Build()
+	flat := must2.Polygon(newProfile().Vertices())
+	bb := exact.Bounds()
+	if bb.Max.X < 4.5 || bb.Max.Y < 3.5 || bb.Min.X > -1 {
+		t.Errorf("bounds %v do not contain curves", bb)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		p := r2.Vec{X: -2 + rng.Float64()*8, Y: -1 + rng.Float64()*6}
+		got, want := exact.Evaluate(p), flat.Evaluate(p)
+		if math.Abs(got-want) > flatness {
+			t.Fatalf("distance at %v got %g, flattened polygon %g", p, got, want)
+		}
+	}
+	// Points moved against the gradient by their distance land on the curves.
+	const h = 1e-6
+	for i := 0; i < 1000; i++ {
+		p := r2.Vec{X: -2 + rng.Float64()*8, Y: -1 + rng.Float64()*6}
+		d := exact.Evaluate(p)
+		grad := gradient2(exact, p, h)
+		if math.Abs(r2.Norm(grad)-1) > 1e-3 {
+			continue // medial axis.
+		}
+		q := r2.Sub(p, r2.Scale(d, grad))
+		if got := exact.Evaluate(q); math.Abs(got) > 1e-6 {
+			t.Fatalf("distance %g at %v is not exact, got %g at projection %v", d, p, got, q)
+		}
+	}
+}
+
+func TestPolygonCatmullRom(t *testing.T) {
+	// Closed spline through the vertices of a hexagon.
+	p := must2.NewPolygon()
+	vertices := must2.Nagon(6, 2)
+	for _, v := range vertices {
+		p.AddV2(v).CatmullRom()
+	}
+	p.Close()
+	s := p.Build()
+	for _, v := range vertices {
+		if d := s.Evaluate(v); math.Abs(d) > 1e-12 {
+			t.Errorf("spline misses vertex %v by %g", v, d)
+		}
+		// Spline bulges outwards between vertices.
+		if d := s.Evaluate(r2.Scale(0.99, v)); d >= 0 {
+			t.Errorf("got %g inside spline", d)
+		}
+	}
+	if d := s.Evaluate(r2.Vec{X: 0, Y: 1.8}); d >= 0 {
+		t.Errorf("got %g between hexagon edge and spline", d)
+	}
+}
+
+func TestPolygonExactArcs(t *testing.T) {
+	const (
+		l, r     = 3.0, 0.5
+		flatness = 1e-4
+	)
+	slot := func() *must2.PolygonBuilder {
+		p := must2.NewPolygon()
+		p.Add(-l/2, -r)
+		p.Add(l/2, -r)
+		p.Add(l/2, r).Arc(-r, 0)
+		p.Add(-l/2, r)
+		p.Add(-l/2, -r).Arc(-r, 0)
+		p.Flatness(flatness)
+		return p
+	}
+	rounded := func() *must2.PolygonBuilder {
+		p := must2.NewPolygon()
+		p.Add(-l/2, -l/2).Smooth(r, 0)
+		p.Add(l/2, -l/2).Smooth(r, 0)
+		p.Add(l/2, l/2).Smooth(r, 0)
+		p.Add(-l/2, l/2).Smooth(r, 0)
+		p.Close()
+		p.Flatness(flatness)
+		return p
+	}
+	for _, test := range []struct {
+		name    string
+		builder func() *must2.PolygonBuilder
+		want    sdf.SDF2
+	}{
+		{name: "slot", builder: slot, want: must2.Line(l, r)},
+		{name: "rounded", builder: rounded, want: must2.Box(r2.Vec{X: l, Y: l}, r)},
+	} {
+		exact := test.builder().Build()
+		flat := must2.Polygon(test.builder().Vertices())
+		if got, want := exact.Bounds(), test.want.Bounds(); !d2.EqualWithin(got.Min, want.Min, 1e-12) || !d2.EqualWithin(got.Max, want.Max, 1e-12) {
+			t.Errorf("%s: got bounds %v, want %v", test.name, got, want)
+		}
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 2000; i++ {
+			p := r2.Vec{X: -l + rng.Float64()*2*l, Y: -l + rng.Float64()*2*l}
+			want := test.want.Evaluate(p)
+			if got := exact.Evaluate(p); math.Abs(got-want) > 1e-12 {
+				t.Fatalf("%s: distance at %v got %g, want %g", test.name, p, got, want)
+			}
+			if got := flat.Evaluate(p); math.Abs(got-want) > flatness {
+				t.Fatalf("%s: flattened distance at %v got %g, want %g", test.name, p, got, want)
+			}
+		}
+	}
+}
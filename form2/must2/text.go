@@ -0,0 +1,214 @@
+package must2
+
+import (
+	"math"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/soypat/sdf/internal/d2"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// TextAlign is the horizontal alignment of lines of text.
+type TextAlign int
+
+const (
+	// AlignLeft starts lines at the origin.
+	AlignLeft TextAlign = iota
+	// AlignCenter centers lines about the origin.
+	AlignCenter
+	// AlignRight ends lines at the origin.
+	AlignRight
+)
+
+// TextParams defines the layout of text.
+type TextParams struct {
+	// Size is the font size, that is the side of the em square, in model units.
+	Size float64
+	// Align is the horizontal alignment of each line.
+	Align TextAlign
+	// LetterSpacing is extra space added between characters in model units.
+	LetterSpacing float64
+	// LineSpacing is the distance between baselines of consecutive
+	// lines as a multiple of Size. Defaults to 1.2.
+	LineSpacing float64
+}
+
+// text is the 2d signed distance object of the outlines of laid out glyphs.
+type text struct {
+	// glyphs are the closed contours of each glyph.
+	glyphs []*outline
+	bb     r2.Box
+}
+
+// Text returns the SDF2 of a string laid out with font f. The first line
+// has its baseline on the X axis and following lines are laid out below
+// it. Lines are separated by newline characters. Distances are exact
+// distances to the quadratic Bézier glyph outlines and the inside is found
+// with the nonzero winding rule.
+func Text(f *truetype.Font, str string, k TextParams) *text {
+	if f == nil {
+		panic("nil font")
+	}
+	if k.Size <= 0 {
+		panic("text size <= 0")
+	}
+	if k.Align < AlignLeft || k.Align > AlignRight {
+		panic("invalid text alignment")
+	}
+	if k.LineSpacing == 0 {
+		k.LineSpacing = 1.2
+	}
+	// Load glyphs in font units which are converted to model units.
+	upem := f.FUnitsPerEm()
+	scale := fixed.Int26_6(upem)
+	unit := k.Size / float64(upem)
+	var (
+		s   text
+		buf truetype.GlyphBuf
+	)
+	s.bb = r2.Box{Min: d2.Elem(math.Inf(1)), Max: d2.Elem(math.Inf(-1))}
+	for iline, line := range strings.Split(str, "\n") {
+		type placed struct {
+			index truetype.Index
+			x     float64
+		}
+		var (
+			glyphs []placed
+			pen    float64
+			prev   truetype.Index
+		)
+		for i, r := range []rune(line) {
+			index := f.Index(r)
+			if i > 0 {
+				pen += float64(f.Kern(scale, prev, index))*unit + k.LetterSpacing
+			}
+			glyphs = append(glyphs, placed{index: index, x: pen})
+			pen += float64(f.HMetric(scale, index).AdvanceWidth) * unit
+			prev = index
+		}
+		var shift float64
+		switch k.Align {
+		case AlignCenter:
+			shift = -pen / 2
+		case AlignRight:
+			shift = -pen
+		}
+		origin := r2.Vec{X: shift, Y: -float64(iline) * k.LineSpacing * k.Size}
+		for _, g := range glyphs {
+			err := buf.Load(f, scale, g.index, font.HintingNone)
+			if err != nil {
+				panic(err)
+			}
+			segments := glyphSegments(&buf, r2.Add(origin, r2.Vec{X: g.x}), unit)
+			if len(segments) == 0 {
+				continue // whitespace.
+			}
+			glyph := newOutline(segments)
+			s.glyphs = append(s.glyphs, glyph)
+			s.bb = r2.Box(d2.Box(s.bb).Extend(d2.Box(glyph.bb)))
+		}
+	}
+	if len(s.glyphs) == 0 {
+		panic("text has no glyph outlines")
+	}
+	return &s
+}
+
+// glyphSegments converts the TrueType contours of a loaded glyph
+// in font units to segments in model units placed at origin.
+func glyphSegments(buf *truetype.GlyphBuf, origin r2.Vec, unit float64) []segment2 {
+	var segments []segment2
+	add := func(b bezier2) {
+		if b.p0 == b.p2 && (b.line || b.p1 == b.p0) {
+			return // degenerate segment.
+		}
+		segments = append(segments, b)
+	}
+	start := 0
+	for _, end := range buf.Ends {
+		contour := buf.Points[start:end]
+		start = end
+		if len(contour) < 2 {
+			continue
+		}
+		pt := func(i int) (r2.Vec, bool) {
+			p := contour[i%len(contour)]
+			v := r2.Add(origin, r2.Vec{X: float64(p.X) * unit, Y: float64(p.Y) * unit})
+			return v, p.Flags&1 != 0
+		}
+		// Start the contour at an on curve point, which
+		// is implied between two off curve points.
+		first := -1
+		for i := range contour {
+			if _, on := pt(i); on {
+				first = i
+				break
+			}
+		}
+		var current r2.Vec
+		if first == -1 {
+			a, _ := pt(0)
+			b, _ := pt(1)
+			current = r2.Scale(0.5, r2.Add(a, b))
+			first = 0
+		} else {
+			current, _ = pt(first)
+		}
+		contourStart := current
+		// The last point visited is the first point again which closes
+		// the contour. If all points are off curve it is the control
+		// point of the curve ending at the contour start.
+		n := len(contour)
+		for i := 1; i <= n; i++ {
+			p, on := pt(first + i)
+			if on {
+				add(bezier2{p0: current, p2: p, line: true})
+				current = p
+				continue
+			}
+			// Off curve control point. The curve ends at the next
+			// on curve point or halfway to the next control point.
+			next := contourStart
+			if i < n {
+				q, qOn := pt(first + i + 1)
+				if qOn {
+					next = q
+					i++
+				} else {
+					next = r2.Scale(0.5, r2.Add(p, q))
+				}
+			}
+			add(bezier2{p0: current, p1: p, p2: next})
+			current = next
+		}
+	}
+	return segments
+}
+
+// Evaluate returns the minimum distance to the text. Glyphs farther
+// than the closest segment found so far are skipped.
+func (s *text) Evaluate(p r2.Vec) float64 {
+	dist2 := math.Inf(1)
+	winding := 0
+	for _, g := range s.glyphs {
+		bb := d2.Box(g.bb)
+		if !bb.Contains(p) && bb.Dist2(p) >= dist2 {
+			continue
+		}
+		gdist2, gwinding := g.dist2Winding(p)
+		dist2 = math.Min(dist2, gdist2)
+		winding += gwinding
+	}
+	if winding != 0 {
+		return -math.Sqrt(dist2)
+	}
+	return math.Sqrt(dist2)
+}
+
+// Bounds returns the bounding box of the text.
+func (s *text) Bounds() r2.Box {
+	return s.bb
+}
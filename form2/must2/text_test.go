@@ -0,0 +1,85 @@
+package must2_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"golang.org/x/image/font/gofont/goregular"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestText(t *testing.T) {
+	const size = 10.
+	f, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := must2.Text(f, "Sog8", must2.TextParams{Size: size})
+	bb := s.Bounds()
+	// Points moved against the gradient by their distance land on the outline.
+	rng := rand.New(rand.NewSource(1))
+	const h = 1e-6
+	for i := 0; i < 1000; i++ {
+		p := r2.Vec{
+			X: bb.Min.X - 1 + rng.Float64()*(bb.Max.X-bb.Min.X+2),
+			Y: bb.Min.Y - 1 + rng.Float64()*(bb.Max.Y-bb.Min.Y+2),
+		}
+		d := s.Evaluate(p)
+		grad := gradient2(s, p, h)
+		if math.Abs(r2.Norm(grad)-1) > 1e-3 {
+			continue // medial axis.
+		}
+		q := r2.Sub(p, r2.Scale(d, grad))
+		if got := s.Evaluate(q); math.Abs(got) > 1e-4*size {
+			t.Fatalf("distance %g at %v is not exact, got %g at projection %v", d, p, got, q)
+		}
+	}
+	// Glyph interiors.
+	oh := must2.Text(f, "o", must2.TextParams{Size: size})
+	c := d2Center(oh.Bounds())
+	if d := oh.Evaluate(c); d <= 0 {
+		t.Errorf("got %g in hole of o", d)
+	}
+	if d := oh.Evaluate(r2.Vec{X: oh.Bounds().Min.X + 0.1, Y: c.Y}); d >= 0 {
+		t.Errorf("got %g in stroke of o", d)
+	}
+}
+
+func TestTextLayout(t *testing.T) {
+	const size = 10.
+	f, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left := must2.Text(f, "HH", must2.TextParams{Size: size}).Bounds()
+	right := must2.Text(f, "HH", must2.TextParams{Size: size, Align: must2.AlignRight}).Bounds()
+	center := must2.Text(f, "HH", must2.TextParams{Size: size, Align: must2.AlignCenter}).Bounds()
+	spaced := must2.Text(f, "HH", must2.TextParams{Size: size, LetterSpacing: 2}).Bounds()
+	if left.Min.X <= 0 || right.Max.X >= 0 || math.Abs(d2Center(center).X) > 1e-2*size {
+		t.Errorf("misaligned text: left %v, right %v, center %v", left, right, center)
+	}
+	if w := spaced.Max.X - left.Max.X; math.Abs(w-2) > 1e-9 {
+		t.Errorf("letter spacing added %g", w)
+	}
+	one := must2.Text(f, "H", must2.TextParams{Size: size}).Bounds()
+	two := must2.Text(f, "H\nH", must2.TextParams{Size: size, LineSpacing: 1.5}).Bounds()
+	if got := two.Min.Y - one.Min.Y; math.Abs(got+1.5*size) > 1e-9 || two.Max.Y != one.Max.Y {
+		t.Errorf("second line baseline offset by %g", got)
+	}
+}
+
+func d2Center(b r2.Box) r2.Vec {
+	return r2.Scale(0.5, r2.Add(b.Min, b.Max))
+}
+
+// gradient2 returns the gradient of s at p by central differences with step h.
+func gradient2(s sdf.SDF2, p r2.Vec, h float64) r2.Vec {
+	return r2.Vec{
+		X: (s.Evaluate(r2.Add(p, r2.Vec{X: h})) - s.Evaluate(r2.Sub(p, r2.Vec{X: h}))) / (2 * h),
+		Y: (s.Evaluate(r2.Add(p, r2.Vec{Y: h})) - s.Evaluate(r2.Sub(p, r2.Vec{Y: h}))) / (2 * h),
+	}
+}
@@ -0,0 +1,27 @@
+package form2
+
+import (
+	"runtime/debug"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+)
+
+// NewPath returns an empty path.
+func NewPath() *must2.PathBuilder {
+	return must2.NewPath()
+}
+
+// BuildPath returns the SDF2 of the closed contours of a path. The
+// inside is found with the fill rule.
+func BuildPath(b *must2.PathBuilder, rule must2.FillRule) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return b.Build(rule), err
+}
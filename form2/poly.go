@@ -39,3 +39,17 @@ func Nagon(n int, radius float64) (s d2.Set, err error) {
 	}()
 	return must2.Nagon(n, radius), err
 }
+
+// BuildPolygon returns an SDF2 of the polygon with exact distances to its
+// curved segments.
+func BuildPolygon(b *must2.PolygonBuilder) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return b.Build(), err
+}
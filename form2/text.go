@@ -0,0 +1,35 @@
+package form2
+
+import (
+	"os"
+	"runtime/debug"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+)
+
+// LoadFont reads and parses a TrueType font file or an
+// OpenType font file with TrueType outlines.
+func LoadFont(path string) (*truetype.Font, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return truetype.Parse(b)
+}
+
+// Text returns the SDF2 of a string laid out with font f. The first line
+// has its baseline on the X axis and following lines are laid out below
+// it. Lines are separated by newline characters.
+func Text(f *truetype.Font, str string, k must2.TextParams) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must2.Text(f, str, k), err
+}
@@ -2,6 +2,7 @@ package form3
 
 import (
 	"fmt"
+	"image"
 	"math"
 	"runtime/debug"
 
@@ -91,3 +92,18 @@ func ChamferedCylinder(s sdf.SDF3, kb, kt float64) (sdf.SDF3, error) {
 	cc := sdf.Revolve3D(s0, 2*math.Pi)
 	return sdf.Intersect3D(s, cc), nil
 }
+
+// Heightmap returns an SDF3 of a solid with a thickness interpolated
+// bilinearly from the luminance of the image pixels, for embossing,
+// engraving, terrain models and lithophanes.
+func Heightmap(img image.Image, k must3.HeightmapParams) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Heightmap(img, k), err
+}
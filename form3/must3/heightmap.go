@@ -0,0 +1,169 @@
+package must3
+
+import (
+	"image"
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// HeightmapParams defines the parameters for a heightmap.
+type HeightmapParams struct {
+	// Size of the image in the XY plane. When wrapped around a
+	// cylinder Size.X is the circumference of the inner face.
+	Size r2.Vec
+	// Thickness of black and white pixels.
+	MinThickness, MaxThickness float64
+	// Invert makes black pixels thickest, as needed for lithophanes.
+	Invert bool
+	// Cylindrical wraps the image around the Z axis so that it reads
+	// correctly when seen from outside. Thickness grows radially outwards.
+	Cylindrical bool
+}
+
+// heightmap is a solid whose thickness follows the luminance of an image.
+type heightmap struct {
+	// thickness at pixel centers, row major starting at the bottom row.
+	height         []float64
+	width, rows    int
+	size           r2.Vec
+	maxThickness   float64
+	radius         float64 // inner radius of cylindrical heightmaps.
+	cylindrical    bool
+	lipschitzInv   float64 // scales the distance to the height surface.
+	pixelW, pixelH float64
+	bb             r3.Box
+}
+
+// Heightmap returns an SDF3 of a solid with a thickness interpolated
+// bilinearly from the luminance of the image pixels, for embossing,
+// engraving, terrain models and lithophanes. Flat heightmaps have their base
+// on the XY plane and the image centered at the origin with the Y axis
+// pointing up. Distances are a lower bound of the euclidean distance scaled
+// by the steepest slope of the surface.
+func Heightmap(img image.Image, k HeightmapParams) *heightmap {
+	if k.Size.X <= 0 || k.Size.Y <= 0 {
+		panic("size <= 0")
+	}
+	if k.MinThickness < 0 || k.MaxThickness <= 0 || k.MaxThickness < k.MinThickness {
+		panic("need 0 <= MinThickness <= MaxThickness and MaxThickness > 0")
+	}
+	rect := img.Bounds()
+	if rect.Empty() {
+		panic("empty image")
+	}
+	s := heightmap{
+		height:       make([]float64, rect.Dx()*rect.Dy()),
+		width:        rect.Dx(),
+		rows:         rect.Dy(),
+		size:         k.Size,
+		maxThickness: k.MaxThickness,
+		cylindrical:  k.Cylindrical,
+		pixelW:       k.Size.X / float64(rect.Dx()),
+		pixelH:       k.Size.Y / float64(rect.Dy()),
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			// Colors are alpha premultiplied, transparent pixels are black.
+			r, g, b, _ := img.At(x, y).RGBA()
+			lum := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+			if k.Invert {
+				lum = 1 - lum
+			}
+			// Flip rows so that Y points up.
+			s.height[(rect.Max.Y-1-y)*s.width+x-rect.Min.X] = k.MinThickness + lum*(k.MaxThickness-k.MinThickness)
+		}
+	}
+	// Bilinear interpolation is no steeper than the
+	// differences between neighbouring pixels.
+	var slopeX, slopeY float64
+	for y := 0; y < s.rows; y++ {
+		for x := 0; x < s.width; x++ {
+			h := s.height[y*s.width+x]
+			if x+1 < s.width {
+				slopeX = math.Max(slopeX, math.Abs(s.height[y*s.width+x+1]-h)/s.pixelW)
+			} else if s.cylindrical {
+				slopeX = math.Max(slopeX, math.Abs(s.height[y*s.width]-h)/s.pixelW)
+			}
+			if y+1 < s.rows {
+				slopeY = math.Max(slopeY, math.Abs(s.height[(y+1)*s.width+x]-h)/s.pixelH)
+			}
+		}
+	}
+	s.lipschitzInv = 1 / math.Sqrt(1+slopeX*slopeX+slopeY*slopeY)
+	if s.cylindrical {
+		s.radius = k.Size.X / (2 * math.Pi)
+		outer := s.radius + k.MaxThickness
+		s.bb = r3.Box{
+			Min: r3.Vec{X: -outer, Y: -outer, Z: -k.Size.Y / 2},
+			Max: r3.Vec{X: outer, Y: outer, Z: k.Size.Y / 2},
+		}
+	} else {
+		s.bb = r3.Box{
+			Min: r3.Vec{X: -k.Size.X / 2, Y: -k.Size.Y / 2},
+			Max: r3.Vec{X: k.Size.X / 2, Y: k.Size.Y / 2, Z: k.MaxThickness},
+		}
+	}
+	return &s
+}
+
+// Evaluate returns the minimum distance to a heightmap.
+func (s *heightmap) Evaluate(p r3.Vec) float64 {
+	if s.cylindrical {
+		rho := math.Hypot(p.X, p.Y)
+		// Arc length along the inner face from the -X axis.
+		u := (math.Atan2(p.Y, p.X) + math.Pi) * s.radius
+		surface := (rho - s.radius - s.sample(u, p.Z+s.size.Y/2)) * s.lipschitzInv
+		inner := s.radius - rho
+		caps := math.Abs(p.Z) - s.size.Y/2
+		return math.Max(surface, math.Max(inner, caps))
+	}
+	half := r3.Vec{X: s.size.X / 2, Y: s.size.Y / 2, Z: s.maxThickness / 2}
+	bounds := sdfBox3d(r3.Sub(p, r3.Vec{Z: half.Z}), half)
+	surface := (p.Z - s.sample(p.X+half.X, p.Y+half.Y)) * s.lipschitzInv
+	return math.Max(surface, bounds)
+}
+
+// sample returns the thickness at (u,v) measured from the bottom
+// left corner of the image. Columns wrap around for cylindrical heightmaps.
+func (s *heightmap) sample(u, v float64) float64 {
+	// Continuous pixel coordinates with pixel centers at integers.
+	x := u/s.pixelW - 0.5
+	y := math.Max(0, math.Min(float64(s.rows-1), v/s.pixelH-0.5))
+	y0 := math.Min(math.Floor(y), math.Max(0, float64(s.rows-2)))
+	fy := y - y0
+	var x0, x1 int
+	var fx float64
+	if s.cylindrical {
+		xf := math.Floor(x)
+		fx = x - xf
+		x0 = int(xf) % s.width
+		if x0 < 0 {
+			x0 += s.width
+		}
+		x1 = (x0 + 1) % s.width
+	} else {
+		x = math.Max(0, math.Min(float64(s.width-1), x))
+		xf := math.Min(math.Floor(x), math.Max(0, float64(s.width-2)))
+		fx = x - xf
+		x0 = int(xf)
+		x1 = x0 + 1
+		if x1 >= s.width {
+			x1 = x0
+		}
+	}
+	r0 := int(y0) * s.width
+	r1 := r0 + s.width
+	if int(y0)+1 >= s.rows {
+		r1 = r0
+	}
+	bottom := s.height[r0+x0]*(1-fx) + s.height[r0+x1]*fx
+	top := s.height[r1+x0]*(1-fx) + s.height[r1+x1]*fx
+	return bottom*(1-fy) + top*fy
+}
+
+// Bounds returns the bounding box for a heightmap.
+func (s *heightmap) Bounds() r3.Box {
+	return s.bb
+}
@@ -0,0 +1,75 @@
+package must3_test
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf/form3/must3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestHeightmap(t *testing.T) {
+	const (
+		minT, maxT = 1., 3.
+		delta      = 0.05
+	)
+	// Horizontal gradient from black to white.
+	img := image.NewGray(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 17)})
+		}
+	}
+	size := r2.Vec{X: 16, Y: 8}
+	for _, invert := range []bool{false, true} {
+		s := must3.Heightmap(img, must3.HeightmapParams{Size: size, MinThickness: minT, MaxThickness: maxT, Invert: invert})
+		if bb := s.Bounds(); bb.Max.Z != maxT || bb.Min.X != -size.X/2 {
+			t.Errorf("invert=%v: got bounds %v", invert, bb)
+		}
+		for x := -7.5; x <= 7.5; x++ {
+			lum := (x + 7.5) / 15
+			if invert {
+				lum = 1 - lum
+			}
+			h := minT + lum*(maxT-minT)
+			p := r3.Vec{X: x, Y: 1}
+			if d := s.Evaluate(r3.Add(p, r3.Vec{Z: h - delta})); d >= 0 {
+				t.Errorf("invert=%v: got %g below surface at x=%g", invert, d, x)
+			}
+			if d := s.Evaluate(r3.Add(p, r3.Vec{Z: h + delta})); d <= 0 {
+				t.Errorf("invert=%v: got %g above surface at x=%g", invert, d, x)
+			}
+			// Distances are a lower bound.
+			if d := s.Evaluate(r3.Add(p, r3.Vec{Z: h + 1})); d > 1 || d < 0.5 {
+				t.Errorf("invert=%v: got distance %g, want at most 1", invert, d)
+			}
+		}
+		if d := s.Evaluate(r3.Vec{X: 10}); math.Abs(d-2) > 1e-12 {
+			t.Errorf("invert=%v: got distance %g to side, want 2", invert, d)
+		}
+	}
+
+	// Uniform gray image wrapped around a cylinder is a tube.
+	gray := image.NewGray(image.Rect(0, 0, 10, 10))
+	for i := range gray.Pix {
+		gray.Pix[i] = 128
+	}
+	radius := size.X / (2 * math.Pi)
+	thickness := 128. / 255 * maxT
+	s := must3.Heightmap(gray, must3.HeightmapParams{Size: size, MaxThickness: maxT, Cylindrical: true})
+	for _, angle := range []float64{0, 1, math.Pi, -2} {
+		dir := r3.Vec{X: math.Cos(angle), Y: math.Sin(angle)}
+		if d := s.Evaluate(r3.Scale(radius+thickness+1, dir)); math.Abs(d-1) > 1e-9 {
+			t.Errorf("got distance %g outside tube, want 1", d)
+		}
+		if d := s.Evaluate(r3.Scale(radius+thickness/2, dir)); d >= 0 {
+			t.Errorf("got distance %g inside tube wall", d)
+		}
+		if d := s.Evaluate(r3.Scale(radius/2, dir)); math.Abs(d-radius/2) > 1e-9 {
+			t.Errorf("got distance %g inside tube, want %g", d, radius/2)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package matter
+
+import (
+	"errors"
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Properties are the mass properties of a solid of uniform density.
+type Properties struct {
+	Volume      float64
+	SurfaceArea float64
+	// Mass is the volume times the density.
+	Mass float64
+	// CenterOfMass is the centroid of the solid.
+	CenterOfMass r3.Vec
+	// Inertia is the inertia tensor about the center of mass
+	// with axes parallel to the coordinate axes.
+	Inertia [3][3]float64
+}
+
+// MassProperties integrates the SDF over its bounding box to find the mass
+// properties of the solid. Space is subdivided with an adaptive octree
+// down to cells of side tolerance near the surface where the volume
+// fraction of cells is estimated from the distance to the surface. Errors
+// decrease linearly with tolerance. Results are accurate for SDFs which
+// return the euclidean distance to the surface.
+func MassProperties(s sdf.SDF3, density, tolerance float64) Properties {
+	if tolerance <= 0 {
+		panic("tolerance must be positive")
+	}
+	bb := d3.Box(s.Bounds())
+	// Cubic root cell with a side that is a power of two multiple of the
+	// leaf cells which are no larger than tolerance. It is padded so that
+	// the surface band of cells is not clipped at the bounding box.
+	size := d3.Max(bb.Size()) + 4*tolerance
+	levels := math.Ceil(math.Log2(size / tolerance))
+	mi := massIntegrator{
+		s:      s,
+		origin: bb.Center(),
+		leaf:   size / math.Exp2(levels),
+	}
+	mi.integrate(bb.Center(), size)
+	p := mi.acc.properties(density, mi.origin)
+	p.SurfaceArea = mi.area
+	return p
+}
+
+// massIntegrator accumulates the mass properties of an SDF3.
+type massIntegrator struct {
+	s sdf.SDF3
+	// moments are taken relative to origin to reduce round off.
+	origin r3.Vec
+	// side of the smallest cells.
+	leaf float64
+	acc  massAccumulator
+	area float64
+}
+
+// integrate adds the contribution of the cube of side size centered at c.
+func (mi *massIntegrator) integrate(c r3.Vec, size float64) {
+	d := mi.s.Evaluate(c)
+	halfDiag := size * math.Sqrt(3) / 2
+	// Cells which may contain leaf cells within a leaf
+	// side length of the surface are subdivided.
+	nearSurface := math.Abs(d) < halfDiag+mi.leaf
+	switch {
+	case nearSurface && size > mi.leaf*1.5:
+		q := size / 4
+		for i := 0; i < 8; i++ {
+			offset := r3.Vec{X: q * float64(2*(i&1)-1), Y: q * float64(2*(i>>1&1)-1), Z: q * float64(2*(i>>2&1)-1)}
+			mi.integrate(r3.Add(c, offset), size/2)
+		}
+	case nearSurface:
+		// Volume fraction ramps linearly across the cell, which is
+		// exact for planar surfaces parallel to the cell faces.
+		fraction := math.Max(0, math.Min(1, 0.5-d/size))
+		mi.acc.addCube(r3.Sub(c, mi.origin), size, fraction)
+		// Surface area from the co-area formula using a hat function of
+		// unit integral as an approximation of the dirac delta.
+		mi.area += size * size * math.Max(0, 1-math.Abs(d)/size)
+	case d < 0:
+		mi.acc.addCube(r3.Sub(c, mi.origin), size, 1)
+	}
+}
+
+// massAccumulator accumulates volume integrals of 1, x and x xᵀ.
+type massAccumulator struct {
+	volume float64
+	first  r3.Vec
+	second [3][3]float64
+}
+
+// addCube adds the fraction of a cube of side size centered at c.
+func (acc *massAccumulator) addCube(c r3.Vec, size, fraction float64) {
+	v := fraction * size * size * size
+	acc.volume += v
+	acc.first = r3.Add(acc.first, r3.Scale(v, c))
+	x := [3]float64{c.X, c.Y, c.Z}
+	for i := range x {
+		for j := range x {
+			acc.second[i][j] += v * x[i] * x[j]
+		}
+		acc.second[i][i] += v * size * size / 12
+	}
+}
+
+// properties returns the mass properties of the accumulated integrals
+// taken relative to origin.
+func (acc *massAccumulator) properties(density float64, origin r3.Vec) Properties {
+	p := Properties{
+		Volume: acc.volume,
+		Mass:   density * acc.volume,
+	}
+	if acc.volume == 0 {
+		return p
+	}
+	com := r3.Scale(1/acc.volume, acc.first)
+	p.CenterOfMass = r3.Add(origin, com)
+	// Second moments about the center of mass using the parallel axis theorem.
+	x := [3]float64{com.X, com.Y, com.Z}
+	var s [3][3]float64
+	for i := range s {
+		for j := range s[i] {
+			s[i][j] = density * (acc.second[i][j] - acc.volume*x[i]*x[j])
+		}
+	}
+	trace := s[0][0] + s[1][1] + s[2][2]
+	for i := range s {
+		for j := range s[i] {
+			p.Inertia[i][j] = -s[i][j]
+		}
+		p.Inertia[i][i] += trace
+	}
+	return p
+}
+
+// MeshMassProperties returns the mass properties of the closed triangle
+// mesh rendered by r. Volume integrals are calculated exactly for the mesh
+// with the divergence theorem as described by David Eberly in "Polyhedral
+// Mass Properties (Revisited)". Triangles must be wound counter-clockwise
+// when seen from outside the solid.
+func MeshMassProperties(r render.Renderer, density float64) (Properties, error) {
+	model, err := render.RenderAll(r)
+	if err != nil {
+		return Properties{}, err
+	}
+	if len(model) == 0 {
+		return Properties{}, errors.New("empty mesh")
+	}
+	// Integrate relative to a mesh vertex to reduce round off.
+	origin := model[0][0]
+	var (
+		area     float64
+		integral [10]float64 // 1, x, y, z, x², y², z², xy, yz, zx
+	)
+	for _, t := range model {
+		area += t.Area()
+		p0, p1, p2 := r3.Sub(t[0], origin), r3.Sub(t[1], origin), r3.Sub(t[2], origin)
+		n := r3.Cross(r3.Sub(p1, p0), r3.Sub(p2, p0))
+		f1x, f2x, f3x, g0x, g1x, g2x := eberlySubexpressions(p0.X, p1.X, p2.X)
+		_, f2y, f3y, g0y, g1y, g2y := eberlySubexpressions(p0.Y, p1.Y, p2.Y)
+		_, f2z, f3z, g0z, g1z, g2z := eberlySubexpressions(p0.Z, p1.Z, p2.Z)
+		integral[0] += n.X * f1x
+		integral[1] += n.X * f2x
+		integral[2] += n.Y * f2y
+		integral[3] += n.Z * f2z
+		integral[4] += n.X * f3x
+		integral[5] += n.Y * f3y
+		integral[6] += n.Z * f3z
+		integral[7] += n.X * (p0.Y*g0x + p1.Y*g1x + p2.Y*g2x)
+		integral[8] += n.Y * (p0.Z*g0y + p1.Z*g1y + p2.Z*g2y)
+		integral[9] += n.Z * (p0.X*g0z + p1.X*g1z + p2.X*g2z)
+	}
+	for i, mult := range [10]float64{1. / 6, 1. / 24, 1. / 24, 1. / 24, 1. / 60, 1. / 60, 1. / 60, 1. / 120, 1. / 120, 1. / 120} {
+		integral[i] *= mult
+	}
+	if integral[0] <= 0 {
+		return Properties{}, errors.New("mesh has no volume or is wound clockwise")
+	}
+	acc := massAccumulator{
+		volume: integral[0],
+		first:  r3.Vec{X: integral[1], Y: integral[2], Z: integral[3]},
+		second: [3][3]float64{
+			{integral[4], integral[7], integral[9]},
+			{integral[7], integral[5], integral[8]},
+			{integral[9], integral[8], integral[6]},
+		},
+	}
+	p := acc.properties(density, origin)
+	p.SurfaceArea = area
+	return p, nil
+}
+
+// eberlySubexpressions returns the subexpressions of the
+// polynomial integrals over a triangle along one axis.
+func eberlySubexpressions(w0, w1, w2 float64) (f1, f2, f3, g0, g1, g2 float64) {
+	temp0 := w0 + w1
+	f1 = temp0 + w2
+	temp1 := w0 * w0
+	temp2 := temp1 + w1*temp0
+	f2 = temp2 + w2*f1
+	f3 = w0*temp1 + w1*temp2 + w2*f2
+	g0 = f2 + w0*(f1+w0)
+	g1 = f2 + w1*(f1+w1)
+	g2 = f2 + w2*(f1+w2)
+	return f1, f2, f3, g0, g1, g2
+}
@@ -0,0 +1,90 @@
+package matter_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/helpers/matter"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestMassProperties(t *testing.T) {
+	const (
+		density = 2.
+		radius  = 1.
+	)
+	offset := r3.Vec{X: 1, Y: -2, Z: 3}
+	sphere := sdf.Transform3D(must3.Sphere(radius), sdf.Translate3D(offset))
+	volume := 4. / 3 * math.Pi * radius * radius * radius
+	want := matter.Properties{
+		Volume:       volume,
+		SurfaceArea:  4 * math.Pi * radius * radius,
+		Mass:         density * volume,
+		CenterOfMass: offset,
+	}
+	for i := range want.Inertia {
+		want.Inertia[i][i] = 2. / 5 * want.Mass * radius * radius
+	}
+	got := matter.MassProperties(sphere, density, 0.01)
+	assertProperties(t, "sdf sphere", got, want, 0.01)
+
+	got, err := matter.MeshMassProperties(render.NewOctreeRenderer(sphere, 100), density)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertProperties(t, "mesh sphere", got, want, 0.01)
+}
+
+func TestMassPropertiesBox(t *testing.T) {
+	const density = 0.5
+	size := r3.Vec{X: 1, Y: 2, Z: 3}
+	box := must3.Box(size, 0)
+	volume := size.X * size.Y * size.Z
+	mass := density * volume
+	want := matter.Properties{
+		Volume:      volume,
+		SurfaceArea: 2 * (size.X*size.Y + size.Y*size.Z + size.Z*size.X),
+		Mass:        mass,
+		Inertia: [3][3]float64{
+			{mass / 12 * (size.Y*size.Y + size.Z*size.Z), 0, 0},
+			{0, mass / 12 * (size.X*size.X + size.Z*size.Z), 0},
+			{0, 0, mass / 12 * (size.X*size.X + size.Y*size.Y)},
+		},
+	}
+	got := matter.MassProperties(box, density, 0.01)
+	assertProperties(t, "sdf box", got, want, 0.01)
+
+	// Marching cubes bevels the edges of the box.
+	got, err := matter.MeshMassProperties(render.NewOctreeRenderer(box, 100), density)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertProperties(t, "mesh box", got, want, 0.02)
+}
+
+func assertProperties(t *testing.T, name string, got, want matter.Properties, relTol float64) {
+	t.Helper()
+	size := math.Cbrt(want.Volume)
+	within := func(a, b, scale float64) bool { return math.Abs(a-b) <= relTol*scale }
+	if !within(got.Volume, want.Volume, want.Volume) || !within(got.Mass, want.Mass, want.Mass) {
+		t.Errorf("%s: got volume %g mass %g, want %g and %g", name, got.Volume, got.Mass, want.Volume, want.Mass)
+	}
+	if !within(got.SurfaceArea, want.SurfaceArea, 2*want.SurfaceArea) {
+		t.Errorf("%s: got surface area %g, want %g", name, got.SurfaceArea, want.SurfaceArea)
+	}
+	if r3.Norm(r3.Sub(got.CenterOfMass, want.CenterOfMass)) > relTol*size {
+		t.Errorf("%s: got center of mass %v, want %v", name, got.CenterOfMass, want.CenterOfMass)
+	}
+	scale := math.Max(want.Inertia[0][0], math.Max(want.Inertia[1][1], want.Inertia[2][2]))
+	for i := range got.Inertia {
+		for j := range got.Inertia[i] {
+			if !within(got.Inertia[i][j], want.Inertia[i][j], 2*scale) {
+				t.Errorf("%s: got inertia %v, want %v", name, got.Inertia, want.Inertia)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package matter
+
+import (
+	"errors"
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d2"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Section are the geometric properties of a cross-section used in beam
+// calculations. Second moments of area are taken about the axes through
+// the centroid parallel to the coordinate axes.
+type Section struct {
+	Area      float64
+	Perimeter float64
+	Centroid  r2.Vec
+	// Ix is the second moment of area about the x axis, ∫y² dA.
+	Ix float64
+	// Iy is the second moment of area about the y axis, ∫x² dA.
+	Iy float64
+	// Ixy is the product of inertia ∫xy dA.
+	Ixy float64
+	// I1 and I2 are the maximum and minimum principal second moments of area.
+	I1, I2 float64
+	// PrincipalAngle is the angle in radians from the x axis
+	// to the principal axis about which the second moment is I1.
+	PrincipalAngle float64
+	// Sx and Sy are the elastic section moduli about the x and y axes
+	// found by dividing Ix and Iy by the distance from the centroid
+	// to the extreme fiber of the section.
+	Sx, Sy float64
+}
+
+// SectionProperties returns the section properties of the SDF2, i.e. of an
+// extrusion profile or of a Slice2D cut of an SDF3. The outline of the
+// section is found by marching squares with cells no larger than cellSize
+// and its integrals calculated with Green's theorem. Errors decrease with
+// the square of cellSize for smooth outlines.
+func SectionProperties(s sdf.SDF2, cellSize float64) (Section, error) {
+	if cellSize <= 0 {
+		return Section{}, errors.New("cell size must be positive")
+	}
+	// NewQuadRenderer cells have a side of the padded
+	// bounding box length divided by meshCells.
+	bb := d2.Box(s.Bounds())
+	meshCells := int(math.Ceil(1.01 * d2.Max(bb.Size()) / cellSize))
+	if meshCells < 2 {
+		meshCells = 2
+	}
+	lines, err := render.RenderAllLines(render.NewQuadRenderer(s, meshCells))
+	if err != nil {
+		return Section{}, err
+	}
+	return outlineSection(lines, bb.Center())
+}
+
+// outlineSection returns the section properties of the region to the left of
+// the oriented line segments. Integrals are taken relative to origin to
+// reduce round off.
+func outlineSection(lines [][2]r2.Vec, origin r2.Vec) (Section, error) {
+	var (
+		sec         Section
+		first       r2.Vec
+		ix, iy, ixy float64 // about origin.
+	)
+	for _, l := range lines {
+		p0, p1 := r2.Sub(l[0], origin), r2.Sub(l[1], origin)
+		cross := p0.X*p1.Y - p1.X*p0.Y
+		sec.Area += cross / 2
+		sec.Perimeter += r2.Norm(r2.Sub(p1, p0))
+		first.X += (p0.X + p1.X) * cross / 6
+		first.Y += (p0.Y + p1.Y) * cross / 6
+		ix += (p0.Y*p0.Y + p0.Y*p1.Y + p1.Y*p1.Y) * cross / 12
+		iy += (p0.X*p0.X + p0.X*p1.X + p1.X*p1.X) * cross / 12
+		ixy += (p0.X*p1.Y + 2*p0.X*p0.Y + 2*p1.X*p1.Y + p1.X*p0.Y) * cross / 24
+	}
+	if sec.Area <= 0 {
+		return Section{}, errors.New("section has no area")
+	}
+	c := r2.Scale(1/sec.Area, first)
+	sec.Centroid = r2.Add(origin, c)
+	// Parallel axis theorem.
+	sec.Ix = ix - sec.Area*c.Y*c.Y
+	sec.Iy = iy - sec.Area*c.X*c.X
+	sec.Ixy = ixy - sec.Area*c.X*c.Y
+
+	mean := (sec.Ix + sec.Iy) / 2
+	half := (sec.Ix - sec.Iy) / 2
+	radius := math.Hypot(half, sec.Ixy)
+	sec.I1 = mean + radius
+	sec.I2 = mean - radius
+	sec.PrincipalAngle = math.Atan2(-sec.Ixy, half) / 2
+
+	var xMax, yMax float64
+	for _, l := range lines {
+		for _, p := range l {
+			d := r2.Sub(p, sec.Centroid)
+			xMax = math.Max(xMax, math.Abs(d.X))
+			yMax = math.Max(yMax, math.Abs(d.Y))
+		}
+	}
+	sec.Sx = sec.Ix / yMax
+	sec.Sy = sec.Iy / xMax
+	return sec, nil
+}
@@ -0,0 +1,104 @@
+package matter_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/helpers/matter"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestSectionProperties(t *testing.T) {
+	const (
+		b, h    = 2., 4.
+		angle   = math.Pi / 6
+		radius  = 3.
+		cellTol = 3e-3
+	)
+	within := func(got, want, tol float64) bool { return math.Abs(got-want) <= tol*math.Abs(want) }
+	offset := r2.Vec{X: 3, Y: -1}
+	rect := sdf.Transform2D(must2.Box(r2.Vec{X: b, Y: h}, 0), sdf.Translate2D(offset))
+	got, err := matter.SectionProperties(rect, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"area", got.Area, b * h},
+		{"perimeter", got.Perimeter, 2 * (b + h)},
+		{"centroid x", got.Centroid.X, offset.X},
+		{"centroid y", got.Centroid.Y, offset.Y},
+		{"Ix", got.Ix, b * h * h * h / 12},
+		{"Iy", got.Iy, h * b * b * b / 12},
+		{"I1", got.I1, b * h * h * h / 12},
+		{"I2", got.I2, h * b * b * b / 12},
+		{"Sx", got.Sx, b * h * h / 6},
+		{"Sy", got.Sy, h * b * b / 6},
+	} {
+		if !within(c.got, c.want, cellTol) {
+			t.Errorf("rectangle %s: got %g, want %g", c.name, c.got, c.want)
+		}
+	}
+	if math.Abs(got.Ixy) > cellTol || math.Abs(got.PrincipalAngle) > cellTol {
+		t.Errorf("rectangle: got product of inertia %g and principal angle %g, want 0", got.Ixy, got.PrincipalAngle)
+	}
+
+	// Principal axes of a rotated rectangle.
+	rotated := sdf.Transform2D(must2.Box(r2.Vec{X: b, Y: h}, 0), sdf.Rotate2D(angle))
+	got, err = matter.SectionProperties(rotated, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !within(got.I1, b*h*h*h/12, cellTol) || !within(got.I2, h*b*b*b/12, cellTol) {
+		t.Errorf("rotated rectangle: got principal moments %g and %g", got.I1, got.I2)
+	}
+	if math.Abs(got.PrincipalAngle-angle) > cellTol {
+		t.Errorf("rotated rectangle: got principal angle %g, want %g", got.PrincipalAngle, angle)
+	}
+
+	// Circular cross-section of a sphere.
+	slice := sdf.Slice2D(must3.Sphere(radius), r3.Vec{}, r3.Vec{Z: 1})
+	got, err = matter.SectionProperties(slice, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r4 := radius * radius * radius * radius
+	if !within(got.Area, math.Pi*radius*radius, cellTol) || !within(got.Perimeter, 2*math.Pi*radius, cellTol) {
+		t.Errorf("circle: got area %g perimeter %g", got.Area, got.Perimeter)
+	}
+	if !within(got.Ix, math.Pi*r4/4, cellTol) || !within(got.Iy, math.Pi*r4/4, cellTol) {
+		t.Errorf("circle: got Ix %g Iy %g, want %g", got.Ix, got.Iy, math.Pi*r4/4)
+	}
+	if !within(got.Sx, math.Pi*r4/4/radius, cellTol) {
+		t.Errorf("circle: got Sx %g", got.Sx)
+	}
+}
+
+func TestSectionPropertiesConvergence(t *testing.T) {
+	const radius = 3.
+	circle := must2.Circle(radius)
+	area := math.Pi * radius * radius
+	lastErr := math.Inf(1)
+	for _, cellSize := range []float64{0.4, 0.2, 0.1, 0.05} {
+		got, err := matter.SectionProperties(circle, cellSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		relErr := math.Abs(got.Area-area) / area
+		// Chords about a cell long cut off a relative area of
+		// roughly cellSize²/(6*radius²) from the circle.
+		if relErr > cellSize*cellSize/(4*radius*radius) {
+			t.Errorf("cell size %g: area error %g larger than expected", cellSize, relErr)
+		}
+		if relErr >= lastErr {
+			t.Errorf("cell size %g: area error %g did not decrease from %g", cellSize, relErr, lastErr)
+		}
+		lastErr = relErr
+	}
+}
@@ -0,0 +1,610 @@
+package sdfexp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/internal/d2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// DXFParams defines how a DXF drawing is imported.
+type DXFParams struct {
+	// Layers limits the import to entities on the named layers.
+	// Entities on all layers are imported if empty.
+	Layers []string
+	// JoinTolerance is the largest distance between end points of
+	// entities joined into a loop. Defaults to 1e-6 times the largest
+	// dimension of the drawing.
+	JoinTolerance float64
+}
+
+// ImportDXF returns the SDF2 of the closed loops of an ASCII DXF drawing.
+// LINE, ARC, CIRCLE, LWPOLYLINE, SPLINE and ELLIPSE entities in the XY plane
+// are chained into loops and other entities are ignored. Nested loops are
+// holes following the even-odd rule. Lines, circular arcs and non-rational
+// splines up to cubic degree are evaluated exactly, ellipses and other
+// splines are approximated. An error is returned if a loop is not closed.
+func ImportDXF(r io.Reader, k DXFParams) (sdf.SDF2, error) {
+	if k.JoinTolerance < 0 {
+		return nil, errors.New("negative join tolerance")
+	}
+	entities, err := readDXFEntities(r)
+	if err != nil {
+		return nil, err
+	}
+	var pieces []pathPiece
+	for _, e := range entities {
+		if len(k.Layers) > 0 && !e.onLayer(k.Layers) {
+			continue
+		}
+		piece, err := e.piece()
+		if err != nil {
+			return nil, fmt.Errorf("dxf %s entity: %w", e.kind, err)
+		}
+		if len(piece.segs) > 0 {
+			pieces = append(pieces, piece)
+		}
+	}
+	if len(pieces) == 0 {
+		return nil, errors.New("dxf has no supported entities")
+	}
+	tol := k.JoinTolerance
+	if tol == 0 {
+		bb := d2.Box{Min: pieces[0].start, Max: pieces[0].start}
+		for _, p := range pieces {
+			bb = bb.Include(p.start).Include(p.end())
+		}
+		tol = 1e-6 * d2.Max(bb.Size())
+	}
+	b := must2.NewPath()
+	for _, loop := range joinPieces(pieces, tol) {
+		if !d2.EqualWithin(loop.start, loop.end(), tol) {
+			return nil, fmt.Errorf("dxf loop is open from (%g, %g) to (%g, %g), join tolerance is %g",
+				loop.start.X, loop.start.Y, loop.end().X, loop.end().Y, tol)
+		}
+		b.MoveTo(loop.start)
+		loop.appendTo(b)
+	}
+	return b.Build(must2.FillEvenOdd), nil
+}
+
+// joinPieces chains pieces whose end points are within tol into loops.
+// Pieces which can't be joined are returned as open loops.
+func joinPieces(pieces []pathPiece, tol float64) []pathPiece {
+	used := make([]bool, len(pieces))
+	var loops []pathPiece
+	for i := range pieces {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		loop := pathPiece{start: pieces[i].start, segs: append([]pieceSegment(nil), pieces[i].segs...)}
+		for !d2.EqualWithin(loop.start, loop.end(), tol) {
+			// Continue with the nearest free end point.
+			end := loop.end()
+			next, reverse, best := -1, false, tol
+			for j := range pieces {
+				if used[j] {
+					continue
+				}
+				if d := r2.Norm(r2.Sub(pieces[j].start, end)); d <= best {
+					next, reverse, best = j, false, d
+				}
+				if d := r2.Norm(r2.Sub(pieces[j].end(), end)); d <= best {
+					next, reverse, best = j, true, d
+				}
+			}
+			if next == -1 {
+				break
+			}
+			used[next] = true
+			piece := pieces[next]
+			if reverse {
+				piece = piece.reverse()
+			}
+			loop.segs = append(loop.segs, piece.segs...)
+		}
+		loops = append(loops, loop)
+	}
+	return loops
+}
+
+// dxfGroup is a group code and value pair.
+type dxfGroup struct {
+	code  int
+	value string
+}
+
+// dxfEntity is an entity of the ENTITIES section.
+type dxfEntity struct {
+	kind   string
+	groups []dxfGroup
+}
+
+// readDXFEntities returns the entities of an ASCII DXF file.
+func readDXFEntities(r io.Reader) ([]dxfEntity, error) {
+	sc := bufio.NewScanner(r)
+	line := 0
+	next := func() (g dxfGroup, ok bool, err error) {
+		if !sc.Scan() {
+			return g, false, sc.Err()
+		}
+		line++
+		code := strings.TrimSpace(sc.Text())
+		g.code, err = strconv.Atoi(code)
+		if err != nil {
+			return g, false, fmt.Errorf("dxf line %d: invalid group code %q", line, code)
+		}
+		if !sc.Scan() {
+			if sc.Err() != nil {
+				return g, false, sc.Err()
+			}
+			return g, false, fmt.Errorf("dxf line %d: missing value of group %d", line, g.code)
+		}
+		line++
+		g.value = strings.TrimSpace(sc.Text())
+		return g, true, nil
+	}
+	var (
+		entities   []dxfEntity
+		inEntities bool
+		section    bool // previous group started a section.
+	)
+	for {
+		g, ok, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		switch {
+		case section:
+			inEntities = g.code == 2 && g.value == "ENTITIES"
+			section = false
+		case g.code == 0 && g.value == "SECTION":
+			section = true
+		case g.code == 0 && g.value == "ENDSEC":
+			inEntities = false
+		case !inEntities:
+		case g.code == 0:
+			entities = append(entities, dxfEntity{kind: g.value})
+		case len(entities) > 0:
+			e := &entities[len(entities)-1]
+			e.groups = append(e.groups, g)
+		}
+	}
+	return entities, nil
+}
+
+func (e *dxfEntity) onLayer(layers []string) bool {
+	layer := "0"
+	for _, g := range e.groups {
+		if g.code == 8 {
+			layer = g.value
+			break
+		}
+	}
+	for _, l := range layers {
+		if strings.EqualFold(l, layer) {
+			return true
+		}
+	}
+	return false
+}
+
+// floats returns the values of the groups with a code in order of
+// appearance, as needed for repeated groups such as vertices and knots.
+func (e *dxfEntity) floats(code int) ([]float64, error) {
+	var v []float64
+	for _, g := range e.groups {
+		if g.code == code {
+			f, err := strconv.ParseFloat(g.value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("group %d: %w", code, err)
+			}
+			v = append(v, f)
+		}
+	}
+	return v, nil
+}
+
+// float returns the value of the first group with a code or def if missing.
+func (e *dxfEntity) float(code int, def float64) (float64, error) {
+	v, err := e.floats(code)
+	if err != nil || len(v) == 0 {
+		return def, err
+	}
+	return v[0], nil
+}
+
+// points returns the points given by repeated X and Y groups.
+func (e *dxfEntity) points(xcode int) ([]r2.Vec, error) {
+	x, err := e.floats(xcode)
+	if err != nil {
+		return nil, err
+	}
+	y, err := e.floats(xcode + 10)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != len(y) {
+		return nil, fmt.Errorf("%d X and %d Y coordinates", len(x), len(y))
+	}
+	pts := make([]r2.Vec, len(x))
+	for i := range x {
+		pts[i] = r2.Vec{X: x[i], Y: y[i]}
+	}
+	return pts, nil
+}
+
+// point returns the point given by the X and Y groups.
+func (e *dxfEntity) point(xcode int) (r2.Vec, error) {
+	pts, err := e.points(xcode)
+	if err != nil {
+		return r2.Vec{}, err
+	}
+	if len(pts) == 0 {
+		return r2.Vec{}, fmt.Errorf("missing point %d", xcode)
+	}
+	return pts[0], nil
+}
+
+// ocs returns the transform from the object coordinate system of entities
+// drawn in the XY plane, which is mirrored if the extrusion points down.
+func (e *dxfEntity) ocs() (affine, error) {
+	nx, err := e.float(210, 0)
+	if err != nil {
+		return identityAffine, err
+	}
+	ny, err := e.float(220, 0)
+	if err != nil {
+		return identityAffine, err
+	}
+	nz, err := e.float(230, 1)
+	if err != nil {
+		return identityAffine, err
+	}
+	switch {
+	case nx != 0 || ny != 0:
+		return identityAffine, errors.New("entity is not in the XY plane")
+	case nz < 0:
+		return affine{-1, 0, 0, 1, 0, 0}, nil
+	}
+	return identityAffine, nil
+}
+
+// piece returns the outline of a supported entity.
+func (e *dxfEntity) piece() (pathPiece, error) {
+	switch e.kind {
+	case "LINE":
+		p0, err := e.point(10)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		p1, err := e.point(11)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		p := pathPiece{start: p0}
+		if p0 != p1 {
+			p.lineTo(p1)
+		}
+		return p, nil
+	case "ARC", "CIRCLE":
+		m, err := e.ocs()
+		if err != nil {
+			return pathPiece{}, err
+		}
+		c, err := e.point(10)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		r, err := e.float(40, 0)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		if r <= 0 {
+			return pathPiece{}, errors.New("radius <= 0")
+		}
+		start, err := e.float(50, 0)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		end, err := e.float(51, 360)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		if e.kind == "CIRCLE" {
+			start, end = 0, 360
+		}
+		// Arcs are counterclockwise from the start angle to the end angle.
+		for end <= start {
+			end += 360
+		}
+		p := ellipsePiece(c, r2.Vec{X: r}, r2.Vec{Y: r}, start*math.Pi/180, end*math.Pi/180)
+		return p.transform(m), nil
+	case "LWPOLYLINE":
+		return e.lwpolyline()
+	case "ELLIPSE":
+		c, err := e.point(10)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		a, err := e.point(11)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		ratio, err := e.float(40, 1)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		start, err := e.float(41, 0)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		end, err := e.float(42, 2*math.Pi)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		for end <= start {
+			end += 2 * math.Pi
+		}
+		// The minor axis is counterclockwise from the major axis
+		// about the extrusion direction.
+		b := r2.Scale(ratio, r2.Vec{X: -a.Y, Y: a.X})
+		nz, err := e.float(230, 1)
+		if err != nil {
+			return pathPiece{}, err
+		}
+		if nz < 0 {
+			b = r2.Scale(-1, b)
+		}
+		return ellipsePiece(c, a, b, start, end), nil
+	case "SPLINE":
+		return e.spline()
+	}
+	return pathPiece{}, nil
+}
+
+// lwpolyline returns the outline of a polyline with bulged segments.
+func (e *dxfEntity) lwpolyline() (pathPiece, error) {
+	m, err := e.ocs()
+	if err != nil {
+		return pathPiece{}, err
+	}
+	flags, err := e.float(70, 0)
+	if err != nil {
+		return pathPiece{}, err
+	}
+	// Bulges follow the vertex at the start of their segment.
+	var (
+		vertices []r2.Vec
+		bulges   []float64
+	)
+	for _, g := range e.groups {
+		switch g.code {
+		case 10, 20, 42:
+			v, err := strconv.ParseFloat(g.value, 64)
+			if err != nil {
+				return pathPiece{}, fmt.Errorf("group %d: %w", g.code, err)
+			}
+			switch {
+			case g.code == 10:
+				vertices = append(vertices, r2.Vec{X: v})
+				bulges = append(bulges, 0)
+			case len(vertices) == 0:
+				return pathPiece{}, fmt.Errorf("group %d before first vertex", g.code)
+			case g.code == 20:
+				vertices[len(vertices)-1].Y = v
+			default:
+				bulges[len(bulges)-1] = v
+			}
+		}
+	}
+	if len(vertices) == 0 {
+		return pathPiece{}, errors.New("polyline has no vertices")
+	}
+	closed := int(flags)&1 != 0
+	if closed {
+		vertices = append(vertices, vertices[0])
+	}
+	p := pathPiece{start: vertices[0]}
+	for i, v := range vertices[1:] {
+		start, bulge := vertices[i], bulges[i]
+		switch {
+		case v == start:
+			continue
+		case bulge == 0:
+			p.lineTo(v)
+			continue
+		}
+		// The bulge is the tangent of a quarter of the included
+		// angle, positive for counterclockwise arcs.
+		chord := r2.Sub(v, start)
+		normal := r2.Vec{X: -chord.Y, Y: chord.X}
+		c := r2.Add(r2.Scale(0.5, r2.Add(start, v)), r2.Scale((1-bulge*bulge)/(4*bulge), normal))
+		p.arcTo(c, v, bulge > 0)
+	}
+	return p.transform(m), nil
+}
+
+// spline returns the outline of a B-spline given by its control points.
+func (e *dxfEntity) spline() (pathPiece, error) {
+	degreef, err := e.float(71, 3)
+	if err != nil {
+		return pathPiece{}, err
+	}
+	knots, err := e.floats(40)
+	if err != nil {
+		return pathPiece{}, err
+	}
+	weights, err := e.floats(41)
+	if err != nil {
+		return pathPiece{}, err
+	}
+	ctrl, err := e.points(10)
+	if err != nil {
+		return pathPiece{}, err
+	}
+	degree := int(degreef)
+	switch {
+	case len(ctrl) == 0:
+		return pathPiece{}, errors.New("spline without control points")
+	case degree < 1 || len(ctrl) <= degree:
+		return pathPiece{}, fmt.Errorf("%d control points for degree %d", len(ctrl), degree)
+	case len(knots) != len(ctrl)+degree+1:
+		return pathPiece{}, fmt.Errorf("%d knots for %d control points of degree %d", len(knots), len(ctrl), degree)
+	case len(weights) != 0 && len(weights) != len(ctrl):
+		return pathPiece{}, fmt.Errorf("%d weights for %d control points", len(weights), len(ctrl))
+	}
+	for i := 1; i < len(knots); i++ {
+		if knots[i] < knots[i-1] {
+			return pathPiece{}, errors.New("decreasing knots")
+		}
+	}
+	rational := false
+	for _, w := range weights {
+		if w <= 0 {
+			return pathPiece{}, errors.New("weight <= 0")
+		}
+		rational = rational || w != weights[0]
+	}
+	if degree <= 3 && !rational {
+		return bezierPieces(degree, knots, ctrl), nil
+	}
+	bb := d2.Box{Min: ctrl[0], Max: ctrl[0]}
+	for _, c := range ctrl {
+		bb = bb.Include(c)
+	}
+	return flattenSpline(degree, knots, ctrl, weights, 1e-6*d2.Max(bb.Size())), nil
+}
+
+// bezierPieces returns the Bézier curves of a non-rational B-spline of
+// degree up to 3 by inserting knots until each has multiplicity degree.
+func bezierPieces(degree int, knots []float64, ctrl []r2.Vec) pathPiece {
+	lo, hi := knots[degree], knots[len(ctrl)]
+	var values []float64
+	for _, u := range knots {
+		if u >= lo && u <= hi && (len(values) == 0 || u != values[len(values)-1]) {
+			values = append(values, u)
+		}
+	}
+	for _, u := range values {
+		mult := 0
+		for _, k := range knots {
+			if k == u {
+				mult++
+			}
+		}
+		for ; mult < degree; mult++ {
+			knots, ctrl = insertKnot(degree, knots, ctrl, u)
+		}
+	}
+	// Each span is now a Bézier curve given by degree+1 control points.
+	var p pathPiece
+	for j := degree; j < len(ctrl); j++ {
+		if knots[j] >= knots[j+1] || knots[j] < lo || knots[j+1] > hi {
+			continue
+		}
+		c := ctrl[j-degree : j+1]
+		if len(p.segs) == 0 {
+			p.start = c[0]
+		}
+		switch degree {
+		case 1:
+			p.lineTo(c[1])
+		case 2:
+			p.quadTo(c[1], c[2])
+		case 3:
+			p.cubicTo(c[1], c[2], c[3])
+		}
+	}
+	return p
+}
+
+// insertKnot inserts the knot u in a B-spline with Boehm's algorithm.
+func insertKnot(degree int, knots []float64, ctrl []r2.Vec, u float64) ([]float64, []r2.Vec) {
+	k := degree
+	for k+1 < len(ctrl) && knots[k+1] <= u {
+		k++
+	}
+	newCtrl := make([]r2.Vec, len(ctrl)+1)
+	for i := range newCtrl {
+		switch {
+		case i <= k-degree:
+			newCtrl[i] = ctrl[i]
+		case i > k:
+			newCtrl[i] = ctrl[i-1]
+		default:
+			a := (u - knots[i]) / (knots[i+degree] - knots[i])
+			newCtrl[i] = r2.Add(r2.Scale(1-a, ctrl[i-1]), r2.Scale(a, ctrl[i]))
+		}
+	}
+	newKnots := make([]float64, 0, len(knots)+1)
+	newKnots = append(newKnots, knots[:k+1]...)
+	newKnots = append(newKnots, u)
+	newKnots = append(newKnots, knots[k+1:]...)
+	return newKnots, newCtrl
+}
+
+// flattenSpline returns line segments which deviate from a possibly
+// rational B-spline by about tol.
+func flattenSpline(degree int, knots []float64, ctrl []r2.Vec, weights []float64, tol float64) pathPiece {
+	at := func(u float64) r2.Vec {
+		// de Boor's algorithm in homogeneous coordinates.
+		k := degree
+		for k+1 < len(ctrl) && knots[k+1] <= u {
+			k++
+		}
+		d := make([]homogeneous, degree+1)
+		for j := range d {
+			w := 1.
+			if len(weights) > 0 {
+				w = weights[j+k-degree]
+			}
+			c := ctrl[j+k-degree]
+			d[j] = homogeneous{c.X * w, c.Y * w, w}
+		}
+		for r := 1; r <= degree; r++ {
+			for j := degree; j >= r; j-- {
+				i := j + k - degree
+				a := (u - knots[i]) / (knots[i+degree+1-r] - knots[i])
+				for n := range d[j] {
+					d[j][n] = (1-a)*d[j-1][n] + a*d[j][n]
+				}
+			}
+		}
+		return r2.Vec{X: d[degree][0] / d[degree][2], Y: d[degree][1] / d[degree][2]}
+	}
+	p := pathPiece{start: at(knots[degree])}
+	var flatten func(u0, u1 float64, p0, p1 r2.Vec, depth int)
+	flatten = func(u0, u1 float64, p0, p1 r2.Vec, depth int) {
+		um := (u0 + u1) / 2
+		pm := at(um)
+		mid := r2.Scale(0.5, r2.Add(p0, p1))
+		if depth > 16 || (depth > 2 && r2.Norm(r2.Sub(pm, mid)) <= tol) {
+			p.lineTo(p1)
+			return
+		}
+		flatten(u0, um, p0, pm, depth+1)
+		flatten(um, u1, pm, p1, depth+1)
+	}
+	for j := degree; j < len(ctrl); j++ {
+		if knots[j] < knots[j+1] {
+			flatten(knots[j], knots[j+1], at(knots[j]), at(knots[j+1]), 0)
+		}
+	}
+	return p
+}
+
+// homogeneous is a weighted point in homogeneous coordinates.
+type homogeneous [3]float64
@@ -0,0 +1,202 @@
+package sdfexp_test
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/helpers/sdfexp"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// dxfDrawing returns a DXF file with the entities given as group code and value lines.
+func dxfDrawing(entities ...string) string {
+	return "0\nSECTION\n2\nHEADER\n9\n$ACADVER\n1\nAC1015\n0\nENDSEC\n0\nSECTION\n2\nENTITIES\n" +
+		strings.Join(entities, "") + "0\nENDSEC\n0\nEOF\n"
+}
+
+func dxfLine(layer string, p0, p1 r2.Vec) string {
+	return fmt.Sprintf("0\nLINE\n8\n%s\n10\n%g\n20\n%g\n30\n0\n11\n%g\n21\n%g\n31\n0\n", layer, p0.X, p0.Y, p1.X, p1.Y)
+}
+
+func dxfArc(c r2.Vec, r, start, end, extrusion float64) string {
+	return fmt.Sprintf("0\nARC\n8\n0\n10\n%g\n20\n%g\n40\n%g\n210\n0\n220\n0\n230\n%g\n50\n%g\n51\n%g\n", c.X, c.Y, r, extrusion, start, end)
+}
+
+func compareSDF2(t *testing.T, name string, got, want sdf.SDF2, tol float64) {
+	t.Helper()
+	bb := want.Bounds()
+	size := r2.Sub(bb.Max, bb.Min)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		p := r2.Vec{
+			X: bb.Min.X - size.X/4 + rng.Float64()*size.X*1.5,
+			Y: bb.Min.Y - size.Y/4 + rng.Float64()*size.Y*1.5,
+		}
+		if g, w := got.Evaluate(p), want.Evaluate(p); math.Abs(g-w) > tol {
+			t.Fatalf("%s: distance at %v got %g, want %g", name, p, g, w)
+		}
+	}
+}
+
+func TestImportDXFEntities(t *testing.T) {
+	slot := must2.Line(6, 1)
+	// Slot drawn with bulged polyline segments.
+	polyline := "0\nLWPOLYLINE\n8\n0\n90\n4\n70\n1\n" +
+		"10\n-3\n20\n-1\n10\n3\n20\n-1\n42\n1\n10\n3\n20\n1\n10\n-3\n20\n1\n42\n1\n"
+	s, err := sdfexp.ImportDXF(strings.NewReader(dxfDrawing(polyline)), sdfexp.DXFParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSDF2(t, "polyline", s, slot, 1e-12)
+
+	// Slot drawn with lines and arcs in any order and direction and small
+	// gaps. The left arc has a downwards extrusion which mirrors it.
+	const gap = 1e-4
+	pieces := dxfDrawing(
+		dxfLine("0", r2.Vec{X: 3, Y: -1}, r2.Vec{X: -3, Y: -1 + gap}),
+		dxfArc(r2.Vec{X: 3}, 1, 270, 90, -1),
+		dxfLine("0", r2.Vec{X: -3 + gap, Y: 1}, r2.Vec{X: 3, Y: 1}),
+		dxfArc(r2.Vec{X: 3}, 1, 270, 90, 1),
+	)
+	_, err = sdfexp.ImportDXF(strings.NewReader(pieces), sdfexp.DXFParams{})
+	if err == nil {
+		t.Error("expected open loop error with gaps larger than the join tolerance")
+	}
+	s, err = sdfexp.ImportDXF(strings.NewReader(pieces), sdfexp.DXFParams{JoinTolerance: 2 * gap})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSDF2(t, "pieces", s, slot, 2*gap)
+}
+
+func TestImportDXFRing(t *testing.T) {
+	ring := dxfDrawing(
+		"0\nCIRCLE\n8\n0\n10\n0\n20\n0\n40\n5\n",
+		"0\nELLIPSE\n8\n0\n10\n0\n20\n0\n11\n0\n21\n2\n40\n1\n41\n0\n42\n6.283185307179586\n",
+	)
+	s, err := sdfexp.ImportDXF(strings.NewReader(ring), sdfexp.DXFParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		p := r2.Vec{X: -7 + 14*rng.Float64(), Y: -7 + 14*rng.Float64()}
+		want := math.Abs(r2.Norm(p)-3.5) - 1.5
+		if got := s.Evaluate(p); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("distance at %v got %g, want %g", p, got, want)
+		}
+	}
+}
+
+func TestImportDXFSpline(t *testing.T) {
+	// Closed uniform cubic B-spline with wrapped control points.
+	square := []r2.Vec{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 2}, {X: 4, Y: 4}, {X: 2, Y: 4}, {X: 0, Y: 4}, {X: 0, Y: 2}}
+	ctrl := append(square, square[:3]...)
+	var b strings.Builder
+	fmt.Fprintf(&b, "0\nSPLINE\n8\n0\n70\n8\n71\n3\n72\n%d\n73\n%d\n", len(ctrl)+4, len(ctrl))
+	for i := 0; i < len(ctrl)+4; i++ {
+		fmt.Fprintf(&b, "40\n%d\n", i)
+	}
+	for _, c := range ctrl {
+		fmt.Fprintf(&b, "10\n%g\n20\n%g\n30\n0\n", c.X, c.Y)
+	}
+	s, err := sdfexp.ImportDXF(strings.NewReader(dxfDrawing(b.String())), sdfexp.DXFParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The curve passes through (P[i-1] + 4P[i] + P[i+1])/6 at knots.
+	for i := range square {
+		p := r2.Scale(1./6, r2.Add(r2.Add(square[(i+7)%8], r2.Scale(4, square[i])), square[(i+1)%8]))
+		if d := s.Evaluate(p); math.Abs(d) > 1e-12 {
+			t.Errorf("spline misses %v by %g", p, d)
+		}
+	}
+	if d := s.Evaluate(r2.Vec{X: 2, Y: 2}); d > -1 {
+		t.Errorf("got %g at the center of the spline", d)
+	}
+
+	// Rational quadratic B-spline of a circle of radius 2.
+	const w = math.Sqrt2 / 2
+	b.Reset()
+	b.WriteString("0\nSPLINE\n8\n0\n70\n11\n71\n2\n72\n12\n73\n9\n")
+	for _, k := range []float64{0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 4} {
+		fmt.Fprintf(&b, "40\n%g\n", k)
+	}
+	for i, c := range []r2.Vec{{X: 2}, {X: 2, Y: 2}, {Y: 2}, {X: -2, Y: 2}, {X: -2}, {X: -2, Y: -2}, {Y: -2}, {X: 2, Y: -2}, {X: 2}} {
+		weight := 1.
+		if i%2 == 1 {
+			weight = w
+		}
+		fmt.Fprintf(&b, "10\n%g\n20\n%g\n30\n0\n41\n%g\n", c.X, c.Y, weight)
+	}
+	s, err = sdfexp.ImportDXF(strings.NewReader(dxfDrawing(b.String())), sdfexp.DXFParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSDF2(t, "rational spline", s, must2.Circle(2), 1e-5)
+
+	// Splines must have control points.
+	fit := "0\nSPLINE\n8\n0\n71\n3\n74\n2\n11\n0\n21\n0\n11\n1\n21\n1\n"
+	_, err = sdfexp.ImportDXF(strings.NewReader(dxfDrawing(fit)), sdfexp.DXFParams{})
+	if err == nil {
+		t.Error("expected error for spline without control points")
+	}
+}
+
+func TestImportDXFLayers(t *testing.T) {
+	drawing := dxfDrawing(
+		dxfLine("CUT", r2.Vec{}, r2.Vec{X: 4}),
+		dxfLine("CUT", r2.Vec{X: 4}, r2.Vec{X: 4, Y: 4}),
+		dxfLine("CUT", r2.Vec{X: 4, Y: 4}, r2.Vec{}),
+		dxfLine("NOTES", r2.Vec{X: 10}, r2.Vec{X: 20}),
+	)
+	_, err := sdfexp.ImportDXF(strings.NewReader(drawing), sdfexp.DXFParams{})
+	if err == nil {
+		t.Error("expected open loop error for line on notes layer")
+	}
+	s, err := sdfexp.ImportDXF(strings.NewReader(drawing), sdfexp.DXFParams{Layers: []string{"cut"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bb := s.Bounds(); bb.Max.X != 4 {
+		t.Errorf("got bounds %v, want triangle bounds", bb)
+	}
+	_, err = sdfexp.ImportDXF(strings.NewReader(drawing), sdfexp.DXFParams{Layers: []string{"holes"}})
+	if err == nil {
+		t.Error("expected error for empty layer")
+	}
+}
+
+func TestImportDXFRoundTrip(t *testing.T) {
+	panel := sdf.Difference2D(must2.Box(r2.Vec{X: 20, Y: 10}, 2), must2.Circle(3))
+	const cells = 200
+	lines, err := render.RenderAllLines(render.NewQuadRenderer(panel, cells))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = render.WriteDXF(&buf, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := sdfexp.ImportDXF(&buf, sdfexp.DXFParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		p := r2.Vec{X: -12 + 24*rng.Float64(), Y: -7 + 14*rng.Float64()}
+		// Outlines are within a cell of the surface.
+		want := panel.Evaluate(p)
+		if got := s.Evaluate(p); math.Abs(want) > 20./cells && math.Signbit(got) != math.Signbit(want) {
+			t.Fatalf("distance at %v got %g, want %g", p, got, want)
+		}
+	}
+}
@@ -103,7 +103,7 @@ func newMesh(triangles []r3.Triangle, tol float64) (*mesh, error) {
 	for i, tri := range triangles {
 		norm := r3.Unit(tri.Normal())
 		Tform := canalisTransform(tri)
-		InvT := Tform.Inv()
+		InvT := Tform.Inverse()
 		sdfT := meshTriangle{
 			N:    r3.Scale(2*math.Pi, norm),
 			C:    centroid(tri),
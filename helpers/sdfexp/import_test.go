@@ -2,6 +2,7 @@ package sdfexp_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/soypat/sdf/form3"
@@ -22,7 +23,11 @@ func TestImportModel(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fp, _ := os.Create("src.stl")
+	dir := t.TempDir()
+	fp, err := os.Create(filepath.Join(dir, "src.stl"))
+	if err != nil {
+		t.Fatal(err)
+	}
 	defer fp.Close()
 	err = render.WriteSTL(fp, model)
 	if err != nil {
@@ -32,7 +37,7 @@ func TestImportModel(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = render.CreateSTL("imported.stl", render.NewOctreeRenderer(sdf, quality))
+	err = render.CreateSTL(filepath.Join(dir, "imported.stl"), render.NewOctreeRenderer(sdf, quality))
 	if err != nil {
 		t.Fatal(err)
 	}
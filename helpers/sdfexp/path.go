@@ -0,0 +1,139 @@
+package sdfexp
+
+import (
+	"math"
+
+	"github.com/soypat/sdf/form2/must2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// pathPiece is a chain of segments starting at start such as the outline
+// of a drawing entity. It can be reversed to join it to other pieces.
+type pathPiece struct {
+	start r2.Vec
+	segs  []pieceSegment
+}
+
+type pieceKind int
+
+const (
+	pieceLine  pieceKind = iota
+	pieceCubic           // cubic Bézier curve with control points c1 and c2.
+	pieceArc             // circular arc about c1.
+)
+
+// pieceSegment is a segment of a piece ending at end.
+type pieceSegment struct {
+	kind   pieceKind
+	c1, c2 r2.Vec
+	end    r2.Vec
+	ccw    bool // counterclockwise arc.
+}
+
+func (p *pathPiece) lineTo(end r2.Vec) {
+	p.segs = append(p.segs, pieceSegment{kind: pieceLine, end: end})
+}
+
+func (p *pathPiece) quadTo(c, end r2.Vec) {
+	// Elevate the degree of the curve.
+	start := p.end()
+	p.cubicTo(r2.Add(start, r2.Scale(2./3, r2.Sub(c, start))), r2.Add(end, r2.Scale(2./3, r2.Sub(c, end))), end)
+}
+
+func (p *pathPiece) cubicTo(c1, c2, end r2.Vec) {
+	p.segs = append(p.segs, pieceSegment{kind: pieceCubic, c1: c1, c2: c2, end: end})
+}
+
+func (p *pathPiece) arcTo(center, end r2.Vec, ccw bool) {
+	p.segs = append(p.segs, pieceSegment{kind: pieceArc, c1: center, end: end, ccw: ccw})
+}
+
+// end returns the end point of the piece.
+func (p pathPiece) end() r2.Vec {
+	if len(p.segs) == 0 {
+		return p.start
+	}
+	return p.segs[len(p.segs)-1].end
+}
+
+// reverse returns the piece drawn from its end to its start.
+func (p pathPiece) reverse() pathPiece {
+	r := pathPiece{start: p.end(), segs: make([]pieceSegment, len(p.segs))}
+	for i, seg := range p.segs {
+		seg.end = p.start
+		if i > 0 {
+			seg.end = p.segs[i-1].end
+		}
+		seg.c1, seg.c2 = seg.c2, seg.c1
+		if seg.kind == pieceArc {
+			seg.c1 = p.segs[i].c1
+			seg.ccw = !seg.ccw
+		}
+		r.segs[len(p.segs)-1-i] = seg
+	}
+	return r
+}
+
+// transform returns the piece with its points mapped by m.
+func (p pathPiece) transform(m affine) pathPiece {
+	flip := m[0]*m[3]-m[1]*m[2] < 0
+	t := pathPiece{start: m.apply(p.start), segs: make([]pieceSegment, len(p.segs))}
+	for i, seg := range p.segs {
+		seg.c1, seg.c2, seg.end = m.apply(seg.c1), m.apply(seg.c2), m.apply(seg.end)
+		seg.ccw = seg.ccw != flip
+		t.segs[i] = seg
+	}
+	return t
+}
+
+// appendTo draws the segments of the piece from the current point of b.
+func (p pathPiece) appendTo(b *must2.PathBuilder) {
+	for _, seg := range p.segs {
+		switch seg.kind {
+		case pieceLine:
+			b.LineTo(seg.end)
+		case pieceCubic:
+			b.CubicTo(seg.c1, seg.c2, seg.end)
+		case pieceArc:
+			b.ArcTo(seg.c1, seg.end, seg.ccw)
+		}
+	}
+}
+
+// ellipsePiece returns the arc of the ellipse c + a*cos(t) + b*sin(t)
+// from parameter t0 to t1. Circular arcs are exact while elliptical arcs
+// are approximated by cubic Bézier curves.
+func ellipsePiece(c, a, b r2.Vec, t0, t1 float64) pathPiece {
+	at := func(t float64) (pt, tangent r2.Vec) {
+		sin, cos := math.Sincos(t)
+		pt = r2.Add(c, r2.Add(r2.Scale(cos, a), r2.Scale(sin, b)))
+		return pt, r2.Add(r2.Scale(-sin, a), r2.Scale(cos, b))
+	}
+	na, nb := r2.Norm2(a), r2.Norm2(b)
+	circular := math.Abs(na-nb) <= 1e-12*na && math.Abs(r2.Dot(a, b)) <= 1e-12*na
+	maxStep := math.Pi / 2
+	if !circular {
+		// Cubic Bézier curves spanning an eighth of a turn deviate
+		// from a circle by less than 5e-6 times its radius.
+		maxStep = math.Pi / 4
+	}
+	n := int(math.Ceil(math.Abs(t1-t0) / maxStep))
+	if n < 1 {
+		n = 1
+	}
+	step := (t1 - t0) / float64(n)
+	ccw := (r2.Cross(a, b) > 0) == (step > 0)
+	start, _ := at(t0)
+	piece := pathPiece{start: start}
+	for i := 1; i <= n; i++ {
+		p0, d0 := at(t0 + float64(i-1)*step)
+		p1, d1 := at(t0 + float64(i)*step)
+		if circular {
+			piece.arcTo(c, p1, ccw)
+			continue
+		}
+		alpha := 4. / 3 * math.Tan(step/4)
+		piece.cubicTo(r2.Add(p0, r2.Scale(alpha, d0)), r2.Sub(p1, r2.Scale(alpha, d1)), p1)
+	}
+	return piece
+}
@@ -3,7 +3,7 @@ package sdfexp
 import (
 	"math"
 
-	"github.com/soypat/sdf/internal/d3"
+	"github.com/soypat/sdf"
 	"gonum.org/v1/gonum/spatial/kdtree"
 	"gonum.org/v1/gonum/spatial/r2"
 	"gonum.org/v1/gonum/spatial/r3"
@@ -17,10 +17,10 @@ type meshTriangle struct {
 	lastFeature triangleFeature // result from last distance calculation
 	lastClosest r3.Vec
 	Vertices    [3]int
-	m           *mesh        // to be able to construct triangle geometry.
-	N           r3.Vec       // Pseudo Face normal (scaled by 2*pi)
-	T           d3.Transform // Canalis transformation matrix.
-	InvT        d3.Transform // inverse of T
+	m           *mesh   // to be able to construct triangle geometry.
+	N           r3.Vec  // Pseudo Face normal (scaled by 2*pi)
+	T           sdf.M44 // Canalis transformation matrix.
+	InvT        sdf.M44 // inverse of T
 }
 
 func (t *meshTriangle) Compare(c kdtree.Comparable, d kdtree.Dim) float64 {
@@ -46,16 +46,16 @@ func (t *meshTriangle) Distance(c kdtree.Comparable) float64 {
 		}
 		point, t = t, point // make sure `t` is the triangle.
 	}
-	pxy := t.T.Transform(point.C)
+	pxy := t.T.MulPosition(point.C)
 	txy := t.triangle()
 	for i := range txy {
-		txy[i] = t.T.Transform(txy[i])
+		txy[i] = t.T.MulPosition(txy[i])
 	}
 	// We find the closest point to the transformed triangle
 	// in 2D space and then transform the results back to 3D space
 	onTriangle, feat := closestOnTriangle2(lowerVec(pxy), [3]r2.Vec{lowerVec(txy[0]), lowerVec(txy[1]), lowerVec(txy[2])})
 	t.lastFeature = feat
-	t.lastClosest = t.InvT.Transform(r3.Vec{X: onTriangle.X, Y: onTriangle.Y})
+	t.lastClosest = t.InvT.MulPosition(r3.Vec{X: onTriangle.X, Y: onTriangle.Y})
 	return r3.Norm2(r3.Sub(point.C, t.lastClosest))
 }
 
@@ -95,7 +95,7 @@ func (t *meshTriangle) triangle() r3.Triangle {
 //  - the triangle's first edge (t_0,t_1) is on the X axis
 //  - the triangle's first vertex t_0 is at the origin
 //  - the triangle's last vertex t_2 is in the XY plane.
-func canalisTransform(t r3.Triangle) d3.Transform {
+func canalisTransform(t r3.Triangle) sdf.M44 {
 	u2 := r3.Sub(t[1], t[0])
 	u3 := r3.Sub(t[2], t[0])
 
@@ -105,14 +105,14 @@ func canalisTransform(t r3.Triangle) d3.Transform {
 	zc := r3.Cross(xc, yc)
 
 	// Create rotation transform.
-	T := d3.NewTransform([]float64{
+	T := sdf.NewM44([16]float64{
 		xc.X, xc.Y, xc.Z, 0,
 		yc.X, yc.Y, yc.Z, 0,
 		zc.X, zc.Y, zc.Z, 0,
 		0, 0, 0, 1,
 	})
-	t0T := T.Transform(t[0])
-	return T.Translate(r3.Scale(-1, t0T)) // add offset.
+	t0T := T.MulPosition(t[0])
+	return sdf.Translate3D(r3.Scale(-1, t0T)).Mul(T) // add offset.
 }
 
 func (t *meshTriangle) isPoint() bool {
@@ -0,0 +1,558 @@
+package sdfexp
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// SVGParams defines how an SVG drawing is imported.
+type SVGParams struct {
+	// Scale is the size of an SVG user unit in model units. Defaults to 1.
+	// Drawings with a viewBox in millimetres, such as those written by
+	// Inkscape and render.WriteSVG, need no scaling to get millimetres.
+	// Use 25.4/96 to convert CSS pixels to millimetres.
+	Scale float64
+}
+
+// ImportSVG returns the SDF2 of the union of the shapes of an SVG drawing.
+// Supported elements are <path>, <rect>, <circle>, <ellipse>, <polygon>
+// and <polyline> with their transforms and those of enclosing groups.
+// Each shape is filled following its fill-rule regardless of its paint.
+// The Y axis is flipped so the shape is not mirrored with respect to the
+// drawing. Lines, Bézier curves and circular arcs are evaluated exactly,
+// elliptical arcs are approximated by cubic Bézier curves.
+func ImportSVG(r io.Reader, k SVGParams) (sdf.SDF2, error) {
+	if k.Scale < 0 {
+		return nil, errors.New("negative scale")
+	}
+	if k.Scale == 0 {
+		k.Scale = 1
+	}
+	type state struct {
+		m    affine
+		rule must2.FillRule
+		skip bool // element is not rendered.
+	}
+	stack := []state{{m: affine{k.Scale, 0, 0, -k.Scale, 0, 0}}}
+	var shapes []sdf.SDF2
+	dec := xml.NewDecoder(r)
+	dec.Entity = xml.HTMLEntity
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.StartElement:
+			st := stack[len(stack)-1]
+			attrs := svgAttributes(t.Attr)
+			switch t.Name.Local {
+			case "defs", "clipPath", "mask", "symbol", "pattern", "marker", "metadata":
+				st.skip = true
+			}
+			if attrs["display"] == "none" {
+				st.skip = true
+			}
+			switch attrs["fill-rule"] {
+			case "nonzero":
+				st.rule = must2.FillNonZero
+			case "evenodd":
+				st.rule = must2.FillEvenOdd
+			}
+			if tr, ok := attrs["transform"]; ok && !st.skip {
+				m, err := parseSVGTransform(tr)
+				if err != nil {
+					return nil, fmt.Errorf("svg <%s> transform: %w", t.Name.Local, err)
+				}
+				st.m = st.m.mul(m)
+			}
+			stack = append(stack, st)
+			if st.skip {
+				continue
+			}
+			p := svgPath{b: must2.NewPath(), m: st.m}
+			err := p.element(t.Name.Local, attrs)
+			if err != nil {
+				return nil, fmt.Errorf("svg <%s>: %w", t.Name.Local, err)
+			}
+			if !p.b.Empty() {
+				shapes = append(shapes, p.b.Build(st.rule))
+			}
+		}
+	}
+	switch len(shapes) {
+	case 0:
+		return nil, errors.New("svg has no shapes")
+	case 1:
+		return shapes[0], nil
+	}
+	return sdf.Union2D(shapes...), nil
+}
+
+// svgAttributes returns the attributes of an element by local name.
+// Properties in the style attribute override presentation attributes.
+func svgAttributes(attr []xml.Attr) map[string]string {
+	attrs := make(map[string]string, len(attr))
+	for _, a := range attr {
+		attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		if i := strings.IndexByte(decl, ':'); i >= 0 {
+			attrs[strings.TrimSpace(decl[:i])] = strings.TrimSpace(decl[i+1:])
+		}
+	}
+	return attrs
+}
+
+// affine is the transform matrix(a b c d e f) which maps
+// (x, y) to (a*x + c*y + e, b*x + d*y + f).
+type affine [6]float64
+
+var identityAffine = affine{1, 0, 0, 1, 0, 0}
+
+// mul returns the transform which applies n and then m.
+func (m affine) mul(n affine) affine {
+	return affine{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m affine) apply(p r2.Vec) r2.Vec {
+	return r2.Vec{X: m[0]*p.X + m[2]*p.Y + m[4], Y: m[1]*p.X + m[3]*p.Y + m[5]}
+}
+
+// vector returns the vector v mapped by the linear part of the transform.
+func (m affine) vector(v r2.Vec) r2.Vec {
+	return r2.Vec{X: m[0]*v.X + m[2]*v.Y, Y: m[1]*v.X + m[3]*v.Y}
+}
+
+// parseSVGTransform parses a transform attribute, a list of
+// matrix, translate, scale, rotate, skewX and skewY functions.
+func parseSVGTransform(s string) (affine, error) {
+	m := identityAffine
+	sc := svgScanner{s: s}
+	for sc.skipSeparators(); !sc.done(); sc.skipSeparators() {
+		start := sc.i
+		for !sc.done() && isLetter(sc.s[sc.i]) {
+			sc.i++
+		}
+		name := s[start:sc.i]
+		sc.skipSeparators()
+		if sc.done() || sc.s[sc.i] != '(' {
+			return m, fmt.Errorf("expected ( after %q", name)
+		}
+		sc.i++
+		var args []float64
+		for sc.skipSeparators(); !sc.done() && sc.s[sc.i] != ')'; sc.skipSeparators() {
+			v, err := sc.number()
+			if err != nil {
+				return m, err
+			}
+			args = append(args, v)
+		}
+		if sc.done() {
+			return m, fmt.Errorf("missing ) after %s arguments", name)
+		}
+		sc.i++
+		var t affine
+		n := len(args)
+		switch {
+		case name == "matrix" && n == 6:
+			copy(t[:], args)
+		case name == "translate" && (n == 1 || n == 2):
+			args = append(args, 0)
+			t = affine{1, 0, 0, 1, args[0], args[1]}
+		case name == "scale" && (n == 1 || n == 2):
+			args = append(args, args[0])
+			t = affine{args[0], 0, 0, args[1], 0, 0}
+		case name == "rotate" && (n == 1 || n == 3):
+			sin, cos := math.Sincos(args[0] * math.Pi / 180)
+			t = affine{cos, sin, -sin, cos, 0, 0}
+			if n == 3 {
+				t = affine{1, 0, 0, 1, args[1], args[2]}.mul(t).mul(affine{1, 0, 0, 1, -args[1], -args[2]})
+			}
+		case name == "skewX" && n == 1:
+			t = affine{1, 0, math.Tan(args[0] * math.Pi / 180), 1, 0, 0}
+		case name == "skewY" && n == 1:
+			t = affine{1, math.Tan(args[0] * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("invalid transform %s with %d arguments", name, n)
+		}
+		m = m.mul(t)
+	}
+	return m, nil
+}
+
+// svgPath adds the outline of an element to a path in model coordinates.
+type svgPath struct {
+	b *must2.PathBuilder
+	m affine
+}
+
+func (p svgPath) moveTo(v r2.Vec) { p.b.MoveTo(p.m.apply(v)) }
+func (p svgPath) lineTo(v r2.Vec) { p.b.LineTo(p.m.apply(v)) }
+
+func (p svgPath) quadTo(c, v r2.Vec) { p.b.QuadTo(p.m.apply(c), p.m.apply(v)) }
+
+func (p svgPath) cubicTo(c1, c2, v r2.Vec) {
+	p.b.CubicTo(p.m.apply(c1), p.m.apply(c2), p.m.apply(v))
+}
+
+// ellipseTo adds the arc of the ellipse with center c, radii rx and ry
+// rotated by phi from angle theta sweeping dtheta radians, which ends at end.
+func (p svgPath) ellipseTo(c r2.Vec, rx, ry, phi, theta, dtheta float64, end r2.Vec) {
+	// Map the axes of the ellipse to the model.
+	sin, cos := math.Sincos(phi)
+	a := p.m.vector(r2.Vec{X: rx * cos, Y: rx * sin})
+	b := p.m.vector(r2.Vec{X: -ry * sin, Y: ry * cos})
+	piece := ellipsePiece(p.m.apply(c), a, b, theta, theta+dtheta)
+	piece.segs[len(piece.segs)-1].end = p.m.apply(end)
+	piece.appendTo(p.b)
+}
+
+// element adds the outline of a shape element. Other elements are ignored.
+func (p svgPath) element(name string, attrs map[string]string) error {
+	switch name {
+	case "path":
+		return p.path(attrs["d"])
+	case "polygon", "polyline":
+		sc := svgScanner{s: attrs["points"]}
+		var pts []r2.Vec
+		for sc.skipSeparators(); !sc.done(); sc.skipSeparators() {
+			x, err := sc.number()
+			if err != nil {
+				return err
+			}
+			sc.skipSeparators()
+			y, err := sc.number()
+			if err != nil {
+				return err
+			}
+			pts = append(pts, r2.Vec{X: x, Y: y})
+		}
+		if len(pts) < 3 {
+			return nil
+		}
+		p.moveTo(pts[0])
+		for _, v := range pts[1:] {
+			p.lineTo(v)
+		}
+		return nil
+	}
+	var names []string
+	switch name {
+	case "rect":
+		names = []string{"x", "y", "width", "height", "rx", "ry"}
+	case "circle":
+		names = []string{"cx", "cy", "r"}
+	case "ellipse":
+		names = []string{"cx", "cy", "rx", "ry"}
+	default:
+		return nil
+	}
+	v := make(map[string]float64, len(names))
+	for _, attr := range names {
+		length, err := parseSVGLength(attrs[attr])
+		if err != nil {
+			return fmt.Errorf("%s: %w", attr, err)
+		}
+		v[attr] = length
+	}
+	switch name {
+	case "rect":
+		x, y, w, h := v["x"], v["y"], v["width"], v["height"]
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		rx, ry := v["rx"], v["ry"]
+		if _, ok := attrs["ry"]; !ok {
+			ry = rx
+		} else if _, ok := attrs["rx"]; !ok {
+			rx = ry
+		}
+		rx, ry = math.Min(math.Max(rx, 0), w/2), math.Min(math.Max(ry, 0), h/2)
+		if rx == 0 || ry == 0 {
+			p.moveTo(r2.Vec{X: x, Y: y})
+			p.lineTo(r2.Vec{X: x + w, Y: y})
+			p.lineTo(r2.Vec{X: x + w, Y: y + h})
+			p.lineTo(r2.Vec{X: x, Y: y + h})
+			return nil
+		}
+		corner := func(cx, cy, theta float64) {
+			sin, cos := math.Sincos(theta + math.Pi/2)
+			end := r2.Vec{X: cx + rx*cos, Y: cy + ry*sin}
+			p.ellipseTo(r2.Vec{X: cx, Y: cy}, rx, ry, 0, theta, math.Pi/2, end)
+		}
+		p.moveTo(r2.Vec{X: x + rx, Y: y})
+		p.lineTo(r2.Vec{X: x + w - rx, Y: y})
+		corner(x+w-rx, y+ry, -math.Pi/2)
+		p.lineTo(r2.Vec{X: x + w, Y: y + h - ry})
+		corner(x+w-rx, y+h-ry, 0)
+		p.lineTo(r2.Vec{X: x + rx, Y: y + h})
+		corner(x+rx, y+h-ry, math.Pi/2)
+		p.lineTo(r2.Vec{X: x, Y: y + ry})
+		corner(x+rx, y+ry, math.Pi)
+	case "circle", "ellipse":
+		rx, ry := v["r"], v["r"]
+		if name == "ellipse" {
+			rx, ry = v["rx"], v["ry"]
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		c := r2.Vec{X: v["cx"], Y: v["cy"]}
+		start := r2.Vec{X: c.X + rx, Y: c.Y}
+		p.moveTo(start)
+		p.ellipseTo(c, rx, ry, 0, 0, 2*math.Pi, start)
+	}
+	return nil
+}
+
+// svgPathArgs is the number of arguments of each path command.
+var svgPathArgs = map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'C': 6, 'S': 4, 'Q': 4, 'T': 2, 'A': 7, 'Z': 0}
+
+// path adds the subpaths of SVG path data.
+func (p svgPath) path(d string) error {
+	sc := svgScanner{s: d}
+	var (
+		cur, start, ctrl r2.Vec
+		cmd, prev        byte
+	)
+	for sc.skipSeparators(); !sc.done(); sc.skipSeparators() {
+		letter := isLetter(sc.s[sc.i])
+		if letter {
+			cmd = sc.s[sc.i]
+			sc.i++
+		} else if cmd == 0 {
+			return errors.New("path data must start with a command")
+		}
+		upper := cmd &^ 0x20 // upper case command.
+		if prev == 0 && upper != 'M' {
+			return errors.New("path data must start with a moveto")
+		}
+		var args [7]float64
+		n, ok := svgPathArgs[upper]
+		if !ok {
+			return fmt.Errorf("invalid path command %q", cmd)
+		}
+		if n == 0 && !letter {
+			// Commands without arguments are not repeated.
+			return fmt.Errorf("expected command after %c", cmd)
+		}
+		for i := 0; i < n; i++ {
+			sc.skipSeparators()
+			var err error
+			if upper == 'A' && (i == 3 || i == 4) {
+				args[i], err = sc.flag()
+			} else {
+				args[i], err = sc.number()
+			}
+			if err != nil {
+				return fmt.Errorf("path command %q: %w", cmd, err)
+			}
+		}
+		// pt returns the i-th coordinate pair of the command.
+		relative := cmd != upper
+		pt := func(i int) r2.Vec {
+			v := r2.Vec{X: args[i], Y: args[i+1]}
+			if relative {
+				v = r2.Add(v, cur)
+			}
+			return v
+		}
+		// Reflect the last control point for smooth curves
+		// following curves of the same kind.
+		reflected := cur
+		if (upper == 'S' && (prev == 'C' || prev == 'S')) || (upper == 'T' && (prev == 'Q' || prev == 'T')) {
+			reflected = r2.Sub(r2.Scale(2, cur), ctrl)
+		}
+		switch upper {
+		case 'M':
+			cur = pt(0)
+			start = cur
+			p.moveTo(cur)
+			// Following coordinate pairs are implicit lineto commands.
+			cmd = 'L' | cmd&0x20
+		case 'L':
+			cur = pt(0)
+			p.lineTo(cur)
+		case 'H':
+			if relative {
+				args[0] += cur.X
+			}
+			cur.X = args[0]
+			p.lineTo(cur)
+		case 'V':
+			if relative {
+				args[0] += cur.Y
+			}
+			cur.Y = args[0]
+			p.lineTo(cur)
+		case 'C':
+			c1 := pt(0)
+			ctrl, cur = pt(2), pt(4)
+			p.cubicTo(c1, ctrl, cur)
+		case 'S':
+			ctrl, cur = pt(0), pt(2)
+			p.cubicTo(reflected, ctrl, cur)
+		case 'Q':
+			ctrl, cur = pt(0), pt(2)
+			p.quadTo(ctrl, cur)
+		case 'T':
+			ctrl, cur = reflected, pt(0)
+			p.quadTo(ctrl, cur)
+		case 'A':
+			end := pt(5)
+			p.arc(cur, end, args[0], args[1], args[2]*math.Pi/180, args[3] != 0, args[4] != 0)
+			cur = end
+		case 'Z':
+			p.b.Close()
+			cur = start
+		}
+		prev = upper
+	}
+	return nil
+}
+
+// arc adds an SVG elliptical arc from p0 to p1 after converting
+// it to its center parameterization.
+func (p svgPath) arc(p0, p1 r2.Vec, rx, ry, phi float64, large, sweep bool) {
+	if p0 == p1 {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		p.lineTo(p1)
+		return
+	}
+	sinPhi, cosPhi := math.Sincos(phi)
+	half := r2.Scale(0.5, r2.Sub(p0, p1))
+	x1 := cosPhi*half.X + sinPhi*half.Y
+	y1 := -sinPhi*half.X + cosPhi*half.Y
+	// Scale up radii too small to reach the end point.
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx *= math.Sqrt(lambda)
+		ry *= math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	mid := r2.Scale(0.5, r2.Add(p0, p1))
+	c := r2.Add(mid, r2.Vec{X: cosPhi*cx1 - sinPhi*cy1, Y: sinPhi*cx1 + cosPhi*cy1})
+	theta := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	dtheta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - theta
+	if sweep && dtheta < 0 {
+		dtheta += 2 * math.Pi
+	} else if !sweep && dtheta > 0 {
+		dtheta -= 2 * math.Pi
+	}
+	p.ellipseTo(c, rx, ry, phi, theta, dtheta, p1)
+}
+
+// parseSVGLength parses a length attribute in user units.
+// Missing attributes have zero length.
+func parseSVGLength(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	units := map[string]float64{"px": 1, "pt": 4. / 3, "pc": 16, "mm": 96 / 25.4, "cm": 96 / 2.54, "in": 96}
+	scale := 1.
+	for unit, k := range units {
+		if strings.HasSuffix(s, unit) {
+			s, scale = strings.TrimSpace(strings.TrimSuffix(s, unit)), k
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length %q", s)
+	}
+	return v * scale, nil
+}
+
+// svgScanner reads numbers from SVG attribute values.
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (sc *svgScanner) done() bool { return sc.i >= len(sc.s) }
+
+// skipSeparators skips whitespace and commas.
+func (sc *svgScanner) skipSeparators() {
+	for !sc.done() && strings.IndexByte(" \t\r\n,", sc.s[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+// number reads a number which ends where the next one or a command starts,
+// as in "1.5.5-2e3" which holds 1.5, .5 and -2e3.
+func (sc *svgScanner) number() (float64, error) {
+	start := sc.i
+	digits := func() int {
+		n := 0
+		for !sc.done() && sc.s[sc.i] >= '0' && sc.s[sc.i] <= '9' {
+			sc.i++
+			n++
+		}
+		return n
+	}
+	if !sc.done() && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+		sc.i++
+	}
+	n := digits()
+	if !sc.done() && sc.s[sc.i] == '.' {
+		sc.i++
+		n += digits()
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("expected number at %q", sc.s[start:])
+	}
+	if !sc.done() && (sc.s[sc.i] == 'e' || sc.s[sc.i] == 'E') {
+		mark := sc.i
+		sc.i++
+		if !sc.done() && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+			sc.i++
+		}
+		if digits() == 0 {
+			sc.i = mark // not an exponent.
+		}
+	}
+	return strconv.ParseFloat(sc.s[start:sc.i], 64)
+}
+
+// flag reads an arc flag which need not be separated from the next number.
+func (sc *svgScanner) flag() (float64, error) {
+	if sc.done() || (sc.s[sc.i] != '0' && sc.s[sc.i] != '1') {
+		return 0, errors.New("expected arc flag")
+	}
+	sc.i++
+	return float64(sc.s[sc.i-1] - '0'), nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,138 @@
+package sdfexp_test
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/helpers/sdfexp"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+const svgHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="100mm" height="100mm" viewBox="0 0 100 100">
+`
+
+func importSVG(t *testing.T, body string, k sdfexp.SVGParams) sdf.SDF2 {
+	t.Helper()
+	s, err := sdfexp.ImportSVG(strings.NewReader(svgHeader+body+"\n</svg>"), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestImportSVGShapes(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		body string
+		k    sdfexp.SVGParams
+		want sdf.SDF2
+	}{
+		{
+			name: "circle",
+			body: `<circle cx="10" cy="10" r="5"/>`,
+			want: sdf.Transform2D(must2.Circle(5), sdf.Translate2D(r2.Vec{X: 10, Y: -10})),
+		},
+		{
+			name: "scaled circle",
+			body: `<circle cx="10" cy="10" r="5"/>`,
+			k:    sdfexp.SVGParams{Scale: 0.5},
+			want: sdf.Transform2D(must2.Circle(2.5), sdf.Translate2D(r2.Vec{X: 5, Y: -5})),
+		},
+		{
+			name: "rounded rect in group",
+			body: `<g transform="translate(20,0) scale(2)"><rect x="0" y="0" width="5" height="5" rx="1"/></g>`,
+			want: sdf.Transform2D(must2.Box(r2.Vec{X: 10, Y: 10}, 2), sdf.Translate2D(r2.Vec{X: 25, Y: -5})),
+		},
+		{
+			name: "rotated slot path",
+			body: `<path transform="rotate(90 0 0)" d="M-3-1H3A1 1 0 0 1 3 1h-6a1,1 0 01 0-2z"/>`,
+			want: sdf.Transform2D(must2.Line(6, 1), sdf.Rotate2D(-math.Pi/2)),
+		},
+		{
+			name: "polygon",
+			body: `<polygon points="0,0 4,0 4,4 0,4"/>`,
+			want: sdf.Transform2D(must2.Box(r2.Vec{X: 4, Y: 4}, 0), sdf.Translate2D(r2.Vec{X: 2, Y: -2})),
+		},
+	} {
+		compareSDF2(t, test.name, importSVG(t, test.body, test.k), test.want, 1e-9)
+	}
+}
+
+func TestImportSVGFillRule(t *testing.T) {
+	// Concentric circles drawn in the same direction.
+	const d = `d="M-10,0 a10,10 0 1,0 20,0 a10,10 0 1,0 -20,0 M-5,0 a5,5 0 1,0 10,0 a5,5 0 1,0 -10,0"`
+	nonzero := importSVG(t, `<path `+d+`/>`, sdfexp.SVGParams{})
+	evenodd := importSVG(t, `<g style="fill-rule: evenodd"><path `+d+`/></g>`, sdfexp.SVGParams{})
+	if got := nonzero.Evaluate(r2.Vec{}); math.Abs(got+5) > 1e-12 {
+		t.Errorf("nonzero rule got %g at center, want -5", got)
+	}
+	if got := evenodd.Evaluate(r2.Vec{}); math.Abs(got-5) > 1e-12 {
+		t.Errorf("evenodd rule got %g at center, want 5", got)
+	}
+	if got := evenodd.Evaluate(r2.Vec{X: 7.5}); math.Abs(got+2.5) > 1e-12 {
+		t.Errorf("evenodd rule got %g in ring, want -2.5", got)
+	}
+}
+
+func TestImportSVGSmoothCurves(t *testing.T) {
+	for _, test := range [][2]string{
+		{`M0 0 C0 5 5 5 5 0 S10 -5 10 0 Z`, `M0 0 C0 5 5 5 5 0 C5 -5 10 -5 10 0 Z`},
+		{`m0 0 c0 5 5 5 5 0 s5 -5 5 0 z`, `M0 0 C0 5 5 5 5 0 C5 -5 10 -5 10 0 Z`},
+		{`M0 0 Q2.5 5 5 0 T10 0 Z`, `M0 0 Q2.5 5 5 0 Q7.5 -5 10 0 Z`},
+		{`M0 0 q2.5 5 5 0 t5 0 z`, `M0 0 Q2.5 5 5 0 Q7.5 -5 10 0 Z`},
+	} {
+		got := importSVG(t, `<path d="`+test[0]+`"/>`, sdfexp.SVGParams{})
+		want := importSVG(t, `<path d="`+test[1]+`"/>`, sdfexp.SVGParams{})
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 500; i++ {
+			p := r2.Vec{X: -2 + rng.Float64()*14, Y: -6 + rng.Float64()*12}
+			if g, w := got.Evaluate(p), want.Evaluate(p); math.Abs(g-w) > 1e-12 {
+				t.Fatalf("%q: distance at %v got %g, want %g", test[0], p, g, w)
+			}
+		}
+	}
+}
+
+func TestImportSVGEllipse(t *testing.T) {
+	const rx, ry = 4., 2.
+	s := importSVG(t, `<ellipse cx="0" cy="0" rx="4" ry="2" transform="skewX(10)"/>
+<rect width="50" height="50" display="none"/>
+<defs><circle r="50"/></defs>`, sdfexp.SVGParams{})
+	skew := math.Tan(10 * math.Pi / 180)
+	for i := 0; i < 100; i++ {
+		theta := 2 * math.Pi * float64(i) / 100
+		sin, cos := math.Sincos(theta)
+		// Flip Y after skewing.
+		p := r2.Vec{X: rx*cos + skew*ry*sin, Y: -ry * sin}
+		if d := s.Evaluate(p); math.Abs(d) > 5e-6*rx {
+			t.Fatalf("ellipse misses %v by %g", p, d)
+		}
+	}
+	if bb := s.Bounds(); bb.Max.X > 5 || bb.Max.Y > 2.5 {
+		t.Errorf("hidden shapes imported, got bounds %v", bb)
+	}
+}
+
+func TestImportSVGErrors(t *testing.T) {
+	for _, body := range []string{
+		`<path d="L10 10"/>`,
+		`<path d="M0 0 L10"/>`,
+		`<path d="M0 0 X10 10"/>`,
+		`<path d="M0 0 A5 5 0 2 1 10 0"/>`,
+		`<path d="M0 0 L1 0 L1 1 Z 5 5"/>`,
+		`<path d="M0 0 L1 0 L1 1 z5 5"/>`,
+		`<rect width="10%" height="5"/>`,
+		`<g transform="spin(10)"><circle r="1"/></g>`,
+		`<line x1="0" y1="0" x2="10" y2="10"/>`,
+	} {
+		_, err := sdfexp.ImportSVG(strings.NewReader(svgHeader+body+"</svg>"), sdfexp.SVGParams{})
+		if err == nil {
+			t.Errorf("expected error importing %s", body)
+		}
+	}
+}
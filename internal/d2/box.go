@@ -88,6 +88,14 @@ func (a Box) TopLeft() r2.Vec {
 	return r2.Vec{a.Min.X, a.Max.Y}
 }
 
+// Dist2 returns the squared distance from a point to a box.
+// Points within the box have distance = 0.
+func (a Box) Dist2(p r2.Vec) float64 {
+	dx := math.Max(0, math.Max(a.Min.X-p.X, p.X-a.Max.X))
+	dy := math.Max(0, math.Max(a.Min.Y-p.Y, p.Y-a.Max.Y))
+	return dx*dx + dy*dy
+}
+
 // MinMaxDist2 returns the minimum and maximum dist * dist from a point to a box.
 // Points within the box have minimum distance = 0.
 func (a Box) MinMaxDist2(p r2.Vec) r2.Vec {
@@ -95,6 +95,15 @@ func (a Box) Vertices() Set {
 	return v
 }
 
+// Dist2 returns the squared distance from a point to a box.
+// Points within the box have distance = 0.
+func (a Box) Dist2(p r3.Vec) float64 {
+	dx := math.Max(0, math.Max(a.Min.X-p.X, p.X-a.Max.X))
+	dy := math.Max(0, math.Max(a.Min.Y-p.Y, p.Y-a.Max.Y))
+	dz := math.Max(0, math.Max(a.Min.Z-p.Z, p.Z-a.Max.Z))
+	return dx*dx + dy*dy + dz*dz
+}
+
 // MinMaxDist2 returns the minimum and maximum dist * dist from a point to a box.
 // Points within the box have minimum distance = 0.
 func (a Box) MinMaxDist2(p r3.Vec) (min, max float64) {
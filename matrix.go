@@ -6,27 +6,32 @@ import (
 
 	"github.com/soypat/sdf/internal/d2"
 	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/spatial/r2"
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
-// m44 is a 4x4 matrix.
-type m44 struct {
+// M44 is a 4x4 matrix representing an affine transformation of 3D space.
+// It is built with functions such as Translate3D, Rotate3D and ComposeTRS3D
+// and combined with Mul. The zero value is not a valid transform.
+type M44 struct {
 	x00, x01, x02, x03 float64
 	x10, x11, x12, x13 float64
 	x20, x21, x22, x23 float64
 	x30, x31, x32, x33 float64
 }
 
-// m33 is a 3x3 matrix.
-type m33 struct {
+// M33 is a 3x3 matrix representing an affine transformation of 2D space.
+// It is built with functions such as Translate2D, Rotate2D and ComposeTRS2D
+// and combined with Mul. The zero value is not a valid transform.
+type M33 struct {
 	x00, x01, x02 float64
 	x10, x11, x12 float64
 	x20, x21, x22 float64
 }
 
-// m22 is a 2x2 matrix.
-type m22 struct {
+// M22 is a 2x2 matrix.
+type M22 struct {
 	x00, x01 float64
 	x10, x11 float64
 }
@@ -60,8 +65,8 @@ func randomRange(a, b float64) float64 {
 // }
 
 // randomM44 returns a 4x4 matrix with random elements.
-func randomM44(a, b float64) m44 {
-	m := m44{
+func randomM44(a, b float64) M44 {
+	m := M44{
 		randomRange(a, b),
 		randomRange(a, b),
 		randomRange(a, b),
@@ -82,8 +87,8 @@ func randomM44(a, b float64) m44 {
 }
 
 // identity3d returns a 4x4 identity matrix.
-func identity3d() m44 {
-	return m44{
+func identity3d() M44 {
+	return M44{
 		1, 0, 0, 0,
 		0, 1, 0, 0,
 		0, 0, 1, 0,
@@ -91,23 +96,23 @@ func identity3d() m44 {
 }
 
 // identity2d returns a 3x3 identity matrix.
-func identity2d() m33 {
-	return m33{
+func identity2d() M33 {
+	return M33{
 		1, 0, 0,
 		0, 1, 0,
 		0, 0, 1}
 }
 
 // identity returns a 2x2 identity matrix.
-func identity() m22 {
-	return m22{
+func identity() M22 {
+	return M22{
 		1, 0,
 		0, 1}
 }
 
 // Translate3D returns a 4x4 translation matrix.
-func Translate3D(v r3.Vec) m44 {
-	return m44{
+func Translate3D(v r3.Vec) M44 {
+	return M44{
 		1, 0, 0, v.X,
 		0, 1, 0, v.Y,
 		0, 0, 1, v.Z,
@@ -115,8 +120,8 @@ func Translate3D(v r3.Vec) m44 {
 }
 
 // Translate2D returns a 3x3 translation matrix.
-func Translate2D(v r2.Vec) m33 {
-	return m33{
+func Translate2D(v r2.Vec) M33 {
+	return M33{
 		1, 0, v.X,
 		0, 1, v.Y,
 		0, 0, 1}
@@ -124,8 +129,8 @@ func Translate2D(v r2.Vec) m33 {
 
 // Scale3D returns a 4x4 scaling matrix.
 // Scaling does not preserve distance. See: ScaleUniform3D()
-func Scale3D(v r3.Vec) m44 {
-	return m44{
+func Scale3D(v r3.Vec) M44 {
+	return M44{
 		v.X, 0, 0, 0,
 		0, v.Y, 0, 0,
 		0, 0, v.Z, 0,
@@ -134,19 +139,19 @@ func Scale3D(v r3.Vec) m44 {
 
 // Scale2D returns a 3x3 scaling matrix.
 // Scaling does not preserve distance. See: ScaleUniform2D().
-func Scale2D(v r2.Vec) m33 {
-	return m33{
+func Scale2D(v r2.Vec) M33 {
+	return M33{
 		v.X, 0, 0,
 		0, v.Y, 0,
 		0, 0, 1}
 }
 
 // Rotate3D returns an orthographic 4x4 rotation matrix (right hand rule).
-func Rotate3D(v r3.Vec, a float64) m44 {
+func Rotate3D(v r3.Vec, a float64) M44 {
 	v = r3.Unit(v)
 	s, c := math.Sincos(a)
 	m := 1 - c
-	return m44{
+	return M44{
 		m*v.X*v.X + c, m*v.X*v.Y - v.Z*s, m*v.Z*v.X + v.Y*s, 0,
 		m*v.X*v.Y + v.Z*s, m*v.Y*v.Y + c, m*v.Y*v.Z - v.X*s, 0,
 		m*v.Z*v.X - v.Y*s, m*v.Y*v.Z + v.X*s, m*v.Z*v.Z + c, 0,
@@ -155,23 +160,23 @@ func Rotate3D(v r3.Vec, a float64) m44 {
 }
 
 // RotateX returns a 4x4 matrix with rotation about the X axis.
-func RotateX(a float64) m44 {
+func RotateX(a float64) M44 {
 	return Rotate3D(r3.Vec{X: 1, Y: 0, Z: 0}, a)
 }
 
 // RotateY returns a 4x4 matrix with rotation about the Y axis.
-func RotateY(a float64) m44 {
+func RotateY(a float64) M44 {
 	return Rotate3D(r3.Vec{X: 0, Y: 1, Z: 0}, a)
 }
 
 // RotateZ returns a 4x4 matrix with rotation about the Z axis.
-func RotateZ(a float64) m44 {
+func RotateZ(a float64) M44 {
 	return Rotate3D(r3.Vec{X: 0, Y: 0, Z: 1}, a)
 }
 
 // MirrorXY returns a 4x4 matrix with mirroring across the XY plane.
-func MirrorXY() m44 {
-	return m44{
+func MirrorXY() M44 {
+	return M44{
 		1, 0, 0, 0,
 		0, 1, 0, 0,
 		0, 0, -1, 0,
@@ -179,8 +184,8 @@ func MirrorXY() m44 {
 }
 
 // MirrorXZ returns a 4x4 matrix with mirroring across the XZ plane.
-func MirrorXZ() m44 {
-	return m44{
+func MirrorXZ() M44 {
+	return M44{
 		1, 0, 0, 0,
 		0, -1, 0, 0,
 		0, 0, 1, 0,
@@ -188,8 +193,8 @@ func MirrorXZ() m44 {
 }
 
 // MirrorYZ returns a 4x4 matrix with mirroring across the YZ plane.
-func MirrorYZ() m44 {
-	return m44{
+func MirrorYZ() M44 {
+	return M44{
 		-1, 0, 0, 0,
 		0, 1, 0, 0,
 		0, 0, 1, 0,
@@ -197,8 +202,8 @@ func MirrorYZ() m44 {
 }
 
 // MirrorXeqY returns a 4x4 matrix with mirroring across the X == Y plane.
-func MirrorXeqY() m44 {
-	return m44{
+func MirrorXeqY() M44 {
+	return M44{
 		0, 1, 0, 0,
 		1, 0, 0, 0,
 		0, 0, 1, 0,
@@ -206,43 +211,43 @@ func MirrorXeqY() m44 {
 }
 
 // MirrorX returns a 3x3 matrix with mirroring across the X axis.
-func MirrorX() m33 {
-	return m33{
+func MirrorX() M33 {
+	return M33{
 		1, 0, 0,
 		0, -1, 0,
 		0, 0, 1}
 }
 
 // MirrorY returns a 3x3 matrix with mirroring across the Y axis.
-func MirrorY() m33 {
-	return m33{
+func MirrorY() M33 {
+	return M33{
 		-1, 0, 0,
 		0, 1, 0,
 		0, 0, 1}
 }
 
 // Rotate2D returns an orthographic 3x3 rotation matrix (right hand rule).
-func Rotate2D(a float64) m33 {
+func Rotate2D(a float64) M33 {
 	s := math.Sin(a)
 	c := math.Cos(a)
-	return m33{
+	return M33{
 		c, -s, 0,
 		s, c, 0,
 		0, 0, 1}
 }
 
 // Rotate returns an orthographic 2x2 rotation matrix (right hand rule).
-func Rotate(a float64) m22 {
+func Rotate(a float64) M22 {
 	s := math.Sin(a)
 	c := math.Cos(a)
-	return m22{
+	return M22{
 		c, -s,
 		s, c,
 	}
 }
 
 // equals tests the equality of 4x4 matrices.
-func (a m44) equals(b m44, tolerance float64) bool {
+func (a M44) equals(b M44, tolerance float64) bool {
 	return (math.Abs(a.x00-b.x00) < tolerance &&
 		math.Abs(a.x01-b.x01) < tolerance &&
 		math.Abs(a.x02-b.x02) < tolerance &&
@@ -262,7 +267,7 @@ func (a m44) equals(b m44, tolerance float64) bool {
 }
 
 // equals tests the equality of 3x3 matrices.
-func (a m33) equals(b m33, tolerance float64) bool {
+func (a M33) equals(b M33, tolerance float64) bool {
 	return (math.Abs(a.x00-b.x00) < tolerance &&
 		math.Abs(a.x01-b.x01) < tolerance &&
 		math.Abs(a.x02-b.x02) < tolerance &&
@@ -275,7 +280,7 @@ func (a m33) equals(b m33, tolerance float64) bool {
 }
 
 // equals tests the equality of 2x2 matrices.
-func (a m22) equals(b m22, tolerance float64) bool {
+func (a M22) equals(b M22, tolerance float64) bool {
 	return (math.Abs(a.x00-b.x00) < tolerance &&
 		math.Abs(a.x01-b.x01) < tolerance &&
 		math.Abs(a.x10-b.x10) < tolerance &&
@@ -283,20 +288,20 @@ func (a m22) equals(b m22, tolerance float64) bool {
 }
 
 // MulPosition multiplies a V2 position with a rotate/translate matrix.
-func (a m33) MulPosition(b r2.Vec) r2.Vec {
+func (a M33) MulPosition(b r2.Vec) r2.Vec {
 	return r2.Vec{X: a.x00*b.X + a.x01*b.Y + a.x02,
 		Y: a.x10*b.X + a.x11*b.Y + a.x12}
 }
 
 // MulPosition multiplies a V2 position with a rotate matrix.
-func (a m22) MulPosition(b r2.Vec) r2.Vec {
+func (a M22) MulPosition(b r2.Vec) r2.Vec {
 	return r2.Vec{X: a.x00*b.X + a.x01*b.Y,
 		Y: a.x10*b.X + a.x11*b.Y}
 }
 
 // Mul multiplies 4x4 matrices.
-func (a m44) Mul(b m44) m44 {
-	m := m44{}
+func (a M44) Mul(b M44) M44 {
+	m := M44{}
 	m.x00 = a.x00*b.x00 + a.x01*b.x10 + a.x02*b.x20 + a.x03*b.x30
 	m.x10 = a.x10*b.x00 + a.x11*b.x10 + a.x12*b.x20 + a.x13*b.x30
 	m.x20 = a.x20*b.x00 + a.x21*b.x10 + a.x22*b.x20 + a.x23*b.x30
@@ -317,8 +322,8 @@ func (a m44) Mul(b m44) m44 {
 }
 
 // Mul multiplies 3x3 matrices.
-func (a m33) Mul(b m33) m33 {
-	m := m33{}
+func (a M33) Mul(b M33) M33 {
+	m := M33{}
 	m.x00 = a.x00*b.x00 + a.x01*b.x10 + a.x02*b.x20
 	m.x10 = a.x10*b.x00 + a.x11*b.x10 + a.x12*b.x20
 	m.x20 = a.x20*b.x00 + a.x21*b.x10 + a.x22*b.x20
@@ -332,8 +337,8 @@ func (a m33) Mul(b m33) m33 {
 }
 
 // Mul multiplies 2x2 matrices.
-func (a m22) Mul(b m22) m22 {
-	m := m22{}
+func (a M22) Mul(b M22) M22 {
+	m := M22{}
 	m.x00 = a.x00*b.x00 + a.x01*b.x10
 	m.x01 = a.x00*b.x01 + a.x01*b.x11
 	m.x10 = a.x10*b.x00 + a.x11*b.x10
@@ -342,8 +347,8 @@ func (a m22) Mul(b m22) m22 {
 }
 
 // Add two 3x3 matrices.
-func (a m33) Add(b m33) m33 {
-	return m33{
+func (a M33) Add(b M33) M33 {
+	return M33{
 		x00: a.x00 + b.x00,
 		x10: a.x10 + b.x10,
 		x20: a.x20 + b.x20,
@@ -357,8 +362,8 @@ func (a m33) Add(b m33) m33 {
 }
 
 // MulScalar multiplies each 3x3 matrix component by a scalar.
-func (a m33) MulScalar(k float64) m33 {
-	return m33{
+func (a M33) MulScalar(k float64) M33 {
+	return M33{
 		x00: k * a.x00,
 		x10: k * a.x10,
 		x20: k * a.x20,
@@ -375,7 +380,7 @@ func (a m33) MulScalar(k float64) m33 {
 // http://dev.theomader.com/transform-bounding-boxes/
 
 // MulPosition multiplies a r3.Vec position with a rotate/translate matrix.
-func (a m44) MulPosition(b r3.Vec) r3.Vec {
+func (a M44) MulPosition(b r3.Vec) r3.Vec {
 	return r3.Vec{
 		X: a.x00*b.X + a.x01*b.Y + a.x02*b.Z + a.x03,
 		Y: a.x10*b.X + a.x11*b.Y + a.x12*b.Z + a.x13,
@@ -383,7 +388,7 @@ func (a m44) MulPosition(b r3.Vec) r3.Vec {
 }
 
 // MulBox rotates/translates a 3d bounding box and resizes for axis-alignment.
-func (a m44) MulBox(box r3.Box) r3.Box {
+func (a M44) MulBox(box r3.Box) r3.Box {
 	r := r3.Vec{X: a.x00, Y: a.x10, Z: a.x20}
 	u := r3.Vec{X: a.x01, Y: a.x11, Z: a.x21}
 	b := r3.Vec{X: a.x02, Y: a.x12, Z: a.x22}
@@ -404,7 +409,7 @@ func (a m44) MulBox(box r3.Box) r3.Box {
 }
 
 // MulBox rotates/translates a 2d bounding box and resizes for axis-alignment.
-func (a m33) MulBox(box r2.Box) r2.Box {
+func (a M33) MulBox(box r2.Box) r2.Box {
 	r := r2.Vec{X: a.x00, Y: a.x10}
 	u := r2.Vec{X: a.x01, Y: a.x11}
 	t := r2.Vec{X: a.x02, Y: a.x12}
@@ -422,7 +427,7 @@ func (a m33) MulBox(box r2.Box) r2.Box {
 }
 
 // Determinant returns the determinant of a 4x4 matrix.
-func (a m44) Determinant() float64 {
+func (a M44) Determinant() float64 {
 	return (a.x00*a.x11*a.x22*a.x33 - a.x00*a.x11*a.x23*a.x32 +
 		a.x00*a.x12*a.x23*a.x31 - a.x00*a.x12*a.x21*a.x33 +
 		a.x00*a.x13*a.x21*a.x32 - a.x00*a.x13*a.x22*a.x31 -
@@ -438,20 +443,20 @@ func (a m44) Determinant() float64 {
 }
 
 // Determinant returns the determinant of a 3x3 matrix.
-func (a m33) Determinant() float64 {
+func (a M33) Determinant() float64 {
 	return (a.x00*(a.x11*a.x22-a.x21*a.x12) -
 		a.x01*(a.x10*a.x22-a.x20*a.x12) +
 		a.x02*(a.x10*a.x21-a.x20*a.x11))
 }
 
 // Determinant returns the determinant of a 2x2 matrix.
-func (a m22) Determinant() float64 {
+func (a M22) Determinant() float64 {
 	return a.x00*a.x11 - a.x01*a.x10
 }
 
 // Inverse returns the inverse of a 4x4 matrix.
-func (a m44) Inverse() m44 {
-	m := m44{}
+func (a M44) Inverse() M44 {
+	m := M44{}
 	d := 1 / a.Determinant()
 	m.x00 = (a.x12*a.x23*a.x31 - a.x13*a.x22*a.x31 + a.x13*a.x21*a.x32 - a.x11*a.x23*a.x32 - a.x12*a.x21*a.x33 + a.x11*a.x22*a.x33) * d
 	m.x01 = (a.x03*a.x22*a.x31 - a.x02*a.x23*a.x31 - a.x03*a.x21*a.x32 + a.x01*a.x23*a.x32 + a.x02*a.x21*a.x33 - a.x01*a.x22*a.x33) * d
@@ -473,8 +478,8 @@ func (a m44) Inverse() m44 {
 }
 
 // Inverse returns the inverse of a 3x3 matrix.
-func (a m33) Inverse() m33 {
-	m := m33{}
+func (a M33) Inverse() M33 {
+	m := M33{}
 	d := 1 / a.Determinant()
 	m.x00 = (a.x11*a.x22 - a.x12*a.x21) * d
 	m.x01 = (a.x21*a.x02 - a.x01*a.x22) * d
@@ -489,8 +494,8 @@ func (a m33) Inverse() m33 {
 }
 
 // Inverse returns the inverse of a 2x2 matrix.
-func (a m22) Inverse() m22 {
-	m := m22{}
+func (a M22) Inverse() M22 {
+	m := M22{}
 	d := 1 / a.Determinant()
 	m.x00 = a.x11 * d
 	m.x01 = -a.x01 * d
@@ -500,7 +505,7 @@ func (a m22) Inverse() m22 {
 }
 
 // rotateToVector returns the rotation matrix that transforms a onto the same direction as b.
-func rotateToVec(a, b r3.Vec) m44 {
+func rotateToVec(a, b r3.Vec) M44 {
 	// is either vector == 0?
 	if d3.EqualWithin(a, r3.Vec{}, epsilon) || d3.EqualWithin(b, r3.Vec{}, epsilon) {
 		return identity3d()
@@ -515,7 +520,7 @@ func rotateToVec(a, b r3.Vec) m44 {
 
 	// are the vectors opposite (180 degrees apart)?
 	if d3.EqualWithin(r3.Scale(-1, a), b, epsilon) {
-		return m44{
+		return M44{
 			-1, 0, 0, 0,
 			0, -1, 0, 0,
 			0, 0, -1, 0,
@@ -535,10 +540,224 @@ func rotateToVec(a, b r3.Vec) m44 {
 	// Calculate sum of matrices.
 	vx.Add(vx, r3.Eye())
 	vx.Add(vx, vx2)
-	return m44{
+	return M44{
 		vx.At(0, 0), vx.At(0, 1), vx.At(0, 2), 0,
 		vx.At(1, 0), vx.At(1, 1), vx.At(1, 2), 0,
 		vx.At(2, 0), vx.At(2, 1), vx.At(2, 2), 0,
 		0, 0, 0, 1,
 	}
 }
+
+// Identity3D returns the 4x4 identity matrix.
+func Identity3D() M44 { return identity3d() }
+
+// Identity2D returns the 3x3 identity matrix.
+func Identity2D() M33 { return identity2d() }
+
+// NewM44 returns a 4x4 matrix with elements in row-major order.
+func NewM44(v [16]float64) M44 {
+	return M44{
+		v[0], v[1], v[2], v[3],
+		v[4], v[5], v[6], v[7],
+		v[8], v[9], v[10], v[11],
+		v[12], v[13], v[14], v[15]}
+}
+
+// Array returns the elements of the 4x4 matrix in row-major order.
+func (a M44) Array() [16]float64 {
+	return [16]float64{
+		a.x00, a.x01, a.x02, a.x03,
+		a.x10, a.x11, a.x12, a.x13,
+		a.x20, a.x21, a.x22, a.x23,
+		a.x30, a.x31, a.x32, a.x33}
+}
+
+// NewM33 returns a 3x3 matrix with elements in row-major order.
+func NewM33(v [9]float64) M33 {
+	return M33{
+		v[0], v[1], v[2],
+		v[3], v[4], v[5],
+		v[6], v[7], v[8]}
+}
+
+// Array returns the elements of the 3x3 matrix in row-major order.
+func (a M33) Array() [9]float64 {
+	return [9]float64{
+		a.x00, a.x01, a.x02,
+		a.x10, a.x11, a.x12,
+		a.x20, a.x21, a.x22}
+}
+
+// RotateQuat3D returns a 4x4 rotation matrix from a quaternion.
+// The quaternion is normalized so it need not be of unit length.
+func RotateQuat3D(q r3.Rotation) M44 {
+	n := math.Sqrt(q.Real*q.Real + q.Imag*q.Imag + q.Jmag*q.Jmag + q.Kmag*q.Kmag)
+	if n == 0 {
+		panic("zero quaternion")
+	}
+	w, x, y, z := q.Real/n, q.Imag/n, q.Jmag/n, q.Kmag/n
+	return M44{
+		1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y), 0,
+		2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x), 0,
+		2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y), 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Frame3D returns the 4x4 matrix which maps the X, Y and Z axes onto the
+// orthonormal frame at origin with Z axis along z and X axis the component
+// of x perpendicular to z. It is used to place parts in the coordinate
+// system of another.
+func Frame3D(origin, x, z r3.Vec) M44 {
+	ez := r3.Unit(z)
+	ex := r3.Sub(x, r3.Scale(r3.Dot(x, ez), ez))
+	if r3.Norm(ex) < epsilon*r3.Norm(x) || r3.Norm(z) == 0 {
+		panic("frame axes must be non-zero and non-parallel")
+	}
+	ex = r3.Unit(ex)
+	ey := r3.Cross(ez, ex)
+	return M44{
+		ex.X, ey.X, ez.X, origin.X,
+		ex.Y, ey.Y, ez.Y, origin.Y,
+		ex.Z, ey.Z, ez.Z, origin.Z,
+		0, 0, 0, 1,
+	}
+}
+
+// LookAt3D returns the 4x4 rigid transform which places an object at eye
+// with its Z axis pointing towards target and its Y axis as close to up
+// as possible.
+func LookAt3D(eye, target, up r3.Vec) M44 {
+	z := r3.Sub(target, eye)
+	// The X axis is perpendicular to the up vector.
+	return Frame3D(eye, r3.Cross(up, z), z)
+}
+
+// RotateToVec3D returns the 4x4 rotation matrix which
+// rotates direction a onto the direction of b.
+func RotateToVec3D(a, b r3.Vec) M44 {
+	return rotateToVec(a, b)
+}
+
+// ComposeTRS3D returns the 4x4 matrix which scales, then
+// rotates and finally translates. It is the inverse of Decompose.
+func ComposeTRS3D(translation r3.Vec, rotation r3.Rotation, scale r3.Vec) M44 {
+	return Translate3D(translation).Mul(RotateQuat3D(rotation)).Mul(Scale3D(scale))
+}
+
+// Decompose splits an affine 4x4 matrix into translation, rotation and scale
+// such that a = ComposeTRS3D(translation, rotation, scale). Shear can not
+// be represented and is lost. Mirroring transforms yield a negative X scale.
+func (a M44) Decompose() (translation r3.Vec, rotation r3.Rotation, scale r3.Vec) {
+	translation = r3.Vec{X: a.x03, Y: a.x13, Z: a.x23}
+	cx := r3.Vec{X: a.x00, Y: a.x10, Z: a.x20}
+	cy := r3.Vec{X: a.x01, Y: a.x11, Z: a.x21}
+	cz := r3.Vec{X: a.x02, Y: a.x12, Z: a.x22}
+	scale = r3.Vec{X: r3.Norm(cx), Y: r3.Norm(cy), Z: r3.Norm(cz)}
+	if a.Determinant() < 0 {
+		scale.X = -scale.X
+	}
+	if scale.X == 0 || scale.Y == 0 || scale.Z == 0 {
+		panic("can not decompose singular matrix")
+	}
+	cx = r3.Scale(1/scale.X, cx)
+	cy = r3.Scale(1/scale.Y, cy)
+	cz = r3.Scale(1/scale.Z, cz)
+	rotation = quatFromAxes(cx, cy, cz)
+	return translation, rotation, scale
+}
+
+// quatFromAxes returns the unit quaternion of the rotation matrix with
+// columns x, y and z. See "Quaternion Calculus and Fast Animation" by
+// Ken Shoemake.
+func quatFromAxes(x, y, z r3.Vec) r3.Rotation {
+	var q r3.Rotation
+	trace := x.X + y.Y + z.Z
+	switch {
+	case trace > 0:
+		s := 0.5 / math.Sqrt(trace+1)
+		q.Real = 0.25 / s
+		q.Imag = (y.Z - z.Y) * s
+		q.Jmag = (z.X - x.Z) * s
+		q.Kmag = (x.Y - y.X) * s
+	case x.X > y.Y && x.X > z.Z:
+		s := 2 * math.Sqrt(1+x.X-y.Y-z.Z)
+		q.Real = (y.Z - z.Y) / s
+		q.Imag = 0.25 * s
+		q.Jmag = (y.X + x.Y) / s
+		q.Kmag = (z.X + x.Z) / s
+	case y.Y > z.Z:
+		s := 2 * math.Sqrt(1+y.Y-x.X-z.Z)
+		q.Real = (z.X - x.Z) / s
+		q.Imag = (y.X + x.Y) / s
+		q.Jmag = 0.25 * s
+		q.Kmag = (z.Y + y.Z) / s
+	default:
+		s := 2 * math.Sqrt(1+z.Z-x.X-y.Y)
+		q.Real = (x.Y - y.X) / s
+		q.Imag = (z.X + x.Z) / s
+		q.Jmag = (z.Y + y.Z) / s
+		q.Kmag = 0.25 * s
+	}
+	return q
+}
+
+// ComposeTRS2D returns the 3x3 matrix which scales, then rotates by
+// angle radians and finally translates. It is the inverse of Decompose.
+func ComposeTRS2D(translation r2.Vec, angle float64, scale r2.Vec) M33 {
+	return Translate2D(translation).Mul(Rotate2D(angle)).Mul(Scale2D(scale))
+}
+
+// Decompose splits an affine 3x3 matrix into translation, rotation angle
+// and scale such that a = ComposeTRS2D(translation, angle, scale). Shear can
+// not be represented and is lost. Mirroring transforms yield a negative X scale.
+func (a M33) Decompose() (translation r2.Vec, angle float64, scale r2.Vec) {
+	translation = r2.Vec{X: a.x02, Y: a.x12}
+	scale = r2.Vec{X: math.Hypot(a.x00, a.x10), Y: math.Hypot(a.x01, a.x11)}
+	cx := r2.Vec{X: a.x00, Y: a.x10}
+	if a.x00*a.x11-a.x01*a.x10 < 0 {
+		scale.X = -scale.X
+		cx = r2.Scale(-1, cx)
+	}
+	if scale.X == 0 || scale.Y == 0 {
+		panic("can not decompose singular matrix")
+	}
+	angle = math.Atan2(cx.Y, cx.X)
+	return translation, angle, scale
+}
+
+// minSingularValue returns the smallest singular value of the linear part
+// of the 4x4 matrix, which is the smallest factor by which it scales lengths.
+func (a M44) minSingularValue() float64 {
+	var svd mat.SVD
+	ok := svd.Factorize(mat.NewDense(3, 3, []float64{
+		a.x00, a.x01, a.x02,
+		a.x10, a.x11, a.x12,
+		a.x20, a.x21, a.x22,
+	}), mat.SVDNone)
+	if !ok {
+		panic("singular value decomposition failed")
+	}
+	// Values are returned in decreasing order.
+	return svd.Values(nil)[2]
+}
+
+// minSingularValue returns the smallest singular value of the linear part
+// of the 3x3 matrix, which is the smallest factor by which it scales lengths.
+func (a M33) minSingularValue() float64 {
+	// Closed form for the eigenvalues of the 2x2 matrix AᵀA.
+	s := a.x00*a.x00 + a.x01*a.x01 + a.x10*a.x10 + a.x11*a.x11
+	det := a.x00*a.x11 - a.x01*a.x10
+	disc := math.Sqrt(math.Max(s*s-4*det*det, 0))
+	return math.Sqrt(math.Max(s-disc, 0) / 2)
+}
+
+// lipschitzScale returns the factor distances are multiplied by after
+// transforming by a matrix with minimum singular value sigma. Distances
+// of distance preserving transforms are left untouched.
+func lipschitzScale(sigma float64) float64 {
+	if math.Abs(sigma-1) < 1e-12 {
+		return 1
+	}
+	return sigma
+}
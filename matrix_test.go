@@ -0,0 +1,140 @@
+package sdf_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/internal/d2"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestRotateQuat3D(t *testing.T) {
+	const tol = 1e-12
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		axis := r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}
+		angle := 2 * math.Pi * rng.Float64()
+		p := r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}
+		want := r3.Rotate(p, angle, axis)
+		got := sdf.RotateQuat3D(r3.NewRotation(angle, axis)).MulPosition(p)
+		if !d3.EqualWithin(got, want, tol) {
+			t.Fatalf("quaternion rotation: got %v, want %v", got, want)
+		}
+		got = sdf.Rotate3D(axis, angle).MulPosition(p)
+		if !d3.EqualWithin(got, want, tol) {
+			t.Fatalf("axis angle rotation: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDecompose3D(t *testing.T) {
+	const tol = 1e-9
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		translation := r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}
+		axis := r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}
+		rotation := r3.NewRotation(2*math.Pi*rng.Float64(), axis)
+		scale := r3.Vec{X: 0.1 + rng.Float64(), Y: 0.1 + rng.Float64(), Z: 0.1 + rng.Float64()}
+		if i%2 == 1 {
+			scale.X = -scale.X
+		}
+		m := sdf.ComposeTRS3D(translation, rotation, scale)
+		gotT, gotR, gotS := m.Decompose()
+		if !d3.EqualWithin(gotT, translation, tol) || !d3.EqualWithin(gotS, scale, tol) {
+			t.Fatalf("got translation %v and scale %v, want %v and %v", gotT, gotS, translation, scale)
+		}
+		got := sdf.ComposeTRS3D(gotT, gotR, gotS)
+		ga, wa := got.Array(), m.Array()
+		for j := range ga {
+			if math.Abs(ga[j]-wa[j]) > tol {
+				t.Fatalf("recomposed matrix %v, want %v", ga, wa)
+			}
+		}
+		// Inverse undoes the transform.
+		p := r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}
+		if back := m.Inverse().MulPosition(m.MulPosition(p)); !d3.EqualWithin(back, p, tol) {
+			t.Fatalf("inverse: got %v, want %v", back, p)
+		}
+	}
+}
+
+func TestLookAt3D(t *testing.T) {
+	const tol = 1e-12
+	eye := r3.Vec{X: 1, Y: 2, Z: 3}
+	target := r3.Vec{X: 4, Y: 6, Z: 3}
+	m := sdf.LookAt3D(eye, target, r3.Vec{Z: 1})
+	if got := m.MulPosition(r3.Vec{}); !d3.EqualWithin(got, eye, tol) {
+		t.Errorf("origin maps to %v, want %v", got, eye)
+	}
+	if got := m.MulPosition(r3.Vec{Z: 5}); !d3.EqualWithin(got, target, tol) {
+		t.Errorf("Z axis maps to %v, want %v", got, target)
+	}
+	if got := m.MulPosition(r3.Vec{Y: 1}); !d3.EqualWithin(got, r3.Add(eye, r3.Vec{Z: 1}), tol) {
+		t.Errorf("Y axis maps to %v, want up direction", got)
+	}
+	_, _, scale := m.Decompose()
+	if !d3.EqualWithin(scale, r3.Vec{X: 1, Y: 1, Z: 1}, tol) {
+		t.Errorf("look at transform is not rigid, scale %v", scale)
+	}
+}
+
+func TestDecompose2D(t *testing.T) {
+	const tol = 1e-12
+	for _, scale := range []r2.Vec{{X: 2, Y: 0.5}, {X: -1, Y: 3}} {
+		translation := r2.Vec{X: -3, Y: 7}
+		angle := 0.7
+		m := sdf.ComposeTRS2D(translation, angle, scale)
+		gotT, gotA, gotS := m.Decompose()
+		if !d2.EqualWithin(gotT, translation, tol) || !d2.EqualWithin(gotS, scale, tol) || math.Abs(gotA-angle) > tol {
+			t.Errorf("got %v %v %v, want %v %v %v", gotT, gotA, gotS, translation, angle, scale)
+		}
+	}
+}
+
+func TestTransformLipschitz(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	rnd3 := func() r3.Vec {
+		return r3.Vec{X: 4 * rng.NormFloat64(), Y: 4 * rng.NormFloat64(), Z: 4 * rng.NormFloat64()}
+	}
+	rnd2 := func() r2.Vec { return r2.Vec{X: 4 * rng.NormFloat64(), Y: 4 * rng.NormFloat64()} }
+	shear := sdf.NewM44([16]float64{
+		1, 2, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	})
+	for _, m := range []sdf.M44{sdf.Scale3D(r3.Vec{X: 1, Y: 2, Z: 3}), sdf.Scale3D(r3.Vec{X: 0.2, Y: 0.3, Z: 0.4}), shear.Mul(sdf.RotateX(0.3))} {
+		s := sdf.Transform3D(must3.Box(r3.Vec{X: 1, Y: 2, Z: 1}, 0.2), m)
+		for i := 0; i < 1000; i++ {
+			p, q := rnd3(), rnd3()
+			if diff, dist := math.Abs(s.Evaluate(p)-s.Evaluate(q)), r3.Norm(r3.Sub(p, q)); diff > dist*(1+1e-9) {
+				t.Fatalf("distance changes by %g between points %g apart", diff, dist)
+			}
+		}
+	}
+	m2 := sdf.Rotate2D(0.4).Mul(sdf.Scale2D(r2.Vec{X: 3, Y: 0.5}))
+	s2 := sdf.Transform2D(must2.Circle(1), m2)
+	for i := 0; i < 1000; i++ {
+		p, q := rnd2(), rnd2()
+		if diff, dist := math.Abs(s2.Evaluate(p)-s2.Evaluate(q)), r2.Norm(r2.Sub(p, q)); diff > dist*(1+1e-9) {
+			t.Fatalf("2D distance changes by %g between points %g apart", diff, dist)
+		}
+	}
+
+	// Distance preserving transforms are exact.
+	box := must3.Box(r3.Vec{X: 1, Y: 2, Z: 3}, 0)
+	rigid := sdf.Translate3D(r3.Vec{X: 1}).Mul(sdf.RotateY(1))
+	s := sdf.Transform3D(box, rigid)
+	for i := 0; i < 100; i++ {
+		p := rnd3()
+		if got, want := s.Evaluate(p), box.Evaluate(rigid.Inverse().MulPosition(p)); got != want {
+			t.Fatalf("rigid transform got %g, want %g", got, want)
+		}
+	}
+}
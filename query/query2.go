@@ -0,0 +1,121 @@
+package query
+
+import (
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Hit2D is the intersection of a ray with the outline of an SDF2.
+type Hit2D struct {
+	// Position is the point where the ray meets the outline.
+	Position r2.Vec
+	// Distance from the ray origin to Position.
+	Distance float64
+	// Normal is the unit outline normal at Position pointing outwards.
+	Normal r2.Vec
+	// Steps is the number of marching steps taken.
+	Steps int
+}
+
+// Raycast2D marches a ray from origin along dir until it meets the outline
+// of s using sphere tracing. It returns false if the outline is not found
+// within the limits set by opts. Rays starting inside s hit the outline
+// where they exit it.
+func Raycast2D(s sdf.SDF2, origin, dir r2.Vec, opts RaycastOptions) (Hit2D, bool) {
+	bb := d2.Box(s.Bounds())
+	size := d2.Max(bb.Size())
+	opts.setDefaults(size)
+	dir = r2.Unit(dir)
+	t0, t1, ok := rayBox2(bb, origin, dir)
+	if !ok {
+		return Hit2D{}, false
+	}
+	maxDist := t1
+	if opts.MaxDistance > 0 {
+		maxDist = math.Min(maxDist, opts.MaxDistance)
+	}
+	// Start marching at the bounding box since there is no outline before it.
+	t := math.Max(t0, 0)
+	for steps := 1; steps <= opts.MaxSteps && t <= maxDist; steps++ {
+		p := r2.Add(origin, r2.Scale(t, dir))
+		d := math.Abs(s.Evaluate(p))
+		if d < opts.Epsilon {
+			return Hit2D{
+				Position: p,
+				Distance: t,
+				Normal:   normal2(s, p, 1e-5*size),
+				Steps:    steps,
+			}, true
+		}
+		t += d * opts.StepScale
+	}
+	return Hit2D{}, false
+}
+
+// ClosestPoint2D returns the point on the outline of s closest to p.
+// See ClosestPoint for details.
+func ClosestPoint2D(s sdf.SDF2, p r2.Vec) r2.Vec {
+	const maxIterations = 64
+	size := d2.Max(d2.Box(s.Bounds()).Size())
+	tol := 1e-9 * size
+	eps := 1e-5 * size
+	for i := 0; i < maxIterations; i++ {
+		d := s.Evaluate(p)
+		if math.Abs(d) < tol {
+			break
+		}
+		g := gradient2(s, p, eps)
+		g2 := r2.Norm2(g)
+		if g2 == 0 {
+			break
+		}
+		// Newton step towards the zero level set.
+		p = r2.Sub(p, r2.Scale(d/g2, g))
+	}
+	return p
+}
+
+// Normal2D returns the unit normal of s at p, which need not be on the
+// outline, calculated from the SDF gradient.
+func Normal2D(s sdf.SDF2, p r2.Vec) r2.Vec {
+	return normal2(s, p, 1e-5*d2.Max(d2.Box(s.Bounds()).Size()))
+}
+
+// rayBox2 returns the distances along the ray at which it enters and exits
+// the box. ok is false if the ray misses the box or the box is behind it.
+func rayBox2(bb d2.Box, origin, dir r2.Vec) (t0, t1 float64, ok bool) {
+	t0, t1 = math.Inf(-1), math.Inf(1)
+	for _, axis := range [2][4]float64{
+		{origin.X, dir.X, bb.Min.X, bb.Max.X},
+		{origin.Y, dir.Y, bb.Min.Y, bb.Max.Y},
+	} {
+		o, d, min, max := axis[0], axis[1], axis[2], axis[3]
+		if d == 0 {
+			if o < min || o > max {
+				return 0, 0, false
+			}
+			continue
+		}
+		ta, tb := (min-o)/d, (max-o)/d
+		if ta > tb {
+			ta, tb = tb, ta
+		}
+		t0, t1 = math.Max(t0, ta), math.Min(t1, tb)
+	}
+	return t0, t1, t0 <= t1 && t1 >= 0
+}
+
+// gradient2 returns the SDF gradient at p calculated with central differences.
+func gradient2(s sdf.SDF2, p r2.Vec, eps float64) r2.Vec {
+	return r2.Scale(0.5/eps, r2.Vec{
+		X: s.Evaluate(r2.Add(p, r2.Vec{X: eps})) - s.Evaluate(r2.Add(p, r2.Vec{X: -eps})),
+		Y: s.Evaluate(r2.Add(p, r2.Vec{Y: eps})) - s.Evaluate(r2.Add(p, r2.Vec{Y: -eps})),
+	})
+}
+
+func normal2(s sdf.SDF2, p r2.Vec, eps float64) r2.Vec {
+	return r2.Unit(gradient2(s, p, eps))
+}
@@ -0,0 +1,153 @@
+package query
+
+import (
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// RaycastOptions configures ray marching. Zero valued fields take defaults.
+type RaycastOptions struct {
+	// MaxDistance is the maximum distance travelled along the ray. Rays
+	// never travel past the bounding box of the SDF.
+	MaxDistance float64
+	// MaxSteps is the maximum number of SDF evaluations. Defaults to 1000.
+	MaxSteps int
+	// Epsilon is the distance to the surface at which the ray is considered
+	// to hit it. Defaults to 1e-6 times the largest bounding box dimension.
+	Epsilon float64
+	// StepScale multiplies the distance advanced each step. Values
+	// smaller than 1 prevent overshooting the surface of SDFs which
+	// overestimate distance at the cost of more steps. Defaults to 1.
+	StepScale float64
+}
+
+// Hit is the intersection of a ray with the surface of an SDF3.
+type Hit struct {
+	// Position is the point where the ray meets the surface.
+	Position r3.Vec
+	// Distance from the ray origin to Position.
+	Distance float64
+	// Normal is the unit surface normal at Position pointing outwards.
+	Normal r3.Vec
+	// Steps is the number of marching steps taken.
+	Steps int
+}
+
+// Raycast marches a ray from origin along dir until it meets the surface
+// of s using sphere tracing. It returns false if the surface is not found
+// within the limits set by opts. Rays starting inside s hit the surface
+// where they exit it.
+func Raycast(s sdf.SDF3, origin, dir r3.Vec, opts RaycastOptions) (Hit, bool) {
+	bb := d3.Box(s.Bounds())
+	size := d3.Max(bb.Size())
+	opts.setDefaults(size)
+	dir = r3.Unit(dir)
+	t0, t1, ok := rayBox3(bb, origin, dir)
+	if !ok {
+		return Hit{}, false
+	}
+	maxDist := t1
+	if opts.MaxDistance > 0 {
+		maxDist = math.Min(maxDist, opts.MaxDistance)
+	}
+	// Start marching at the bounding box since there is no surface before it.
+	t := math.Max(t0, 0)
+	for steps := 1; steps <= opts.MaxSteps && t <= maxDist; steps++ {
+		p := r3.Add(origin, r3.Scale(t, dir))
+		d := math.Abs(s.Evaluate(p))
+		if d < opts.Epsilon {
+			return Hit{
+				Position: p,
+				Distance: t,
+				Normal:   normal3(s, p, 1e-5*size),
+				Steps:    steps,
+			}, true
+		}
+		t += d * opts.StepScale
+	}
+	return Hit{}, false
+}
+
+// ClosestPoint returns the point on the surface of s closest to p. The point
+// is found by repeatedly moving p along the SDF gradient by the distance to
+// the surface, which converges to the closest point for SDFs which return
+// the exact distance and to a nearby surface point otherwise.
+func ClosestPoint(s sdf.SDF3, p r3.Vec) r3.Vec {
+	const maxIterations = 64
+	size := d3.Max(d3.Box(s.Bounds()).Size())
+	tol := 1e-9 * size
+	eps := 1e-5 * size
+	for i := 0; i < maxIterations; i++ {
+		d := s.Evaluate(p)
+		if math.Abs(d) < tol {
+			break
+		}
+		g := gradient3(s, p, eps)
+		g2 := r3.Norm2(g)
+		if g2 == 0 {
+			break
+		}
+		// Newton step towards the zero level set.
+		p = r3.Sub(p, r3.Scale(d/g2, g))
+	}
+	return p
+}
+
+// Normal returns the unit normal of s at p, which need not be on the
+// surface, calculated from the SDF gradient.
+func Normal(s sdf.SDF3, p r3.Vec) r3.Vec {
+	return normal3(s, p, 1e-5*d3.Max(d3.Box(s.Bounds()).Size()))
+}
+
+func (opts *RaycastOptions) setDefaults(size float64) {
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = 1000
+	}
+	if opts.Epsilon <= 0 {
+		opts.Epsilon = 1e-6 * size
+	}
+	if opts.StepScale <= 0 {
+		opts.StepScale = 1
+	}
+}
+
+// rayBox3 returns the distances along the ray at which it enters and exits
+// the box. ok is false if the ray misses the box or the box is behind it.
+func rayBox3(bb d3.Box, origin, dir r3.Vec) (t0, t1 float64, ok bool) {
+	t0, t1 = math.Inf(-1), math.Inf(1)
+	for _, axis := range [3][4]float64{
+		{origin.X, dir.X, bb.Min.X, bb.Max.X},
+		{origin.Y, dir.Y, bb.Min.Y, bb.Max.Y},
+		{origin.Z, dir.Z, bb.Min.Z, bb.Max.Z},
+	} {
+		o, d, min, max := axis[0], axis[1], axis[2], axis[3]
+		if d == 0 {
+			if o < min || o > max {
+				return 0, 0, false
+			}
+			continue
+		}
+		ta, tb := (min-o)/d, (max-o)/d
+		if ta > tb {
+			ta, tb = tb, ta
+		}
+		t0, t1 = math.Max(t0, ta), math.Min(t1, tb)
+	}
+	return t0, t1, t0 <= t1 && t1 >= 0
+}
+
+// gradient3 returns the SDF gradient at p calculated with central differences.
+func gradient3(s sdf.SDF3, p r3.Vec, eps float64) r3.Vec {
+	return r3.Scale(0.5/eps, r3.Vec{
+		X: s.Evaluate(r3.Add(p, r3.Vec{X: eps})) - s.Evaluate(r3.Add(p, r3.Vec{X: -eps})),
+		Y: s.Evaluate(r3.Add(p, r3.Vec{Y: eps})) - s.Evaluate(r3.Add(p, r3.Vec{Y: -eps})),
+		Z: s.Evaluate(r3.Add(p, r3.Vec{Z: eps})) - s.Evaluate(r3.Add(p, r3.Vec{Z: -eps})),
+	})
+}
+
+func normal3(s sdf.SDF3, p r3.Vec, eps float64) r3.Vec {
+	return r3.Unit(gradient3(s, p, eps))
+}
@@ -0,0 +1,100 @@
+package query_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/internal/d2"
+	"github.com/soypat/sdf/internal/d3"
+	"github.com/soypat/sdf/query"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestRaycast(t *testing.T) {
+	const tol = 1e-4
+	box := sdf.Transform3D(must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0), sdf.Translate3D(r3.Vec{Z: 5}))
+	for _, test := range []struct {
+		name         string
+		origin, dir  r3.Vec
+		wantPos      r3.Vec
+		wantNormal   r3.Vec
+		wantDistance float64
+	}{
+		{name: "front", origin: r3.Vec{}, dir: r3.Vec{Z: 1}, wantPos: r3.Vec{Z: 4}, wantNormal: r3.Vec{Z: -1}, wantDistance: 4},
+		{name: "side", origin: r3.Vec{X: -10, Y: 0.5, Z: 5}, dir: r3.Vec{X: 2}, wantPos: r3.Vec{X: -1, Y: 0.5, Z: 5}, wantNormal: r3.Vec{X: -1}, wantDistance: 9},
+		{name: "inside", origin: r3.Vec{Z: 5}, dir: r3.Vec{Y: 1}, wantPos: r3.Vec{Y: 1, Z: 5}, wantNormal: r3.Vec{Y: 1}, wantDistance: 1},
+	} {
+		hit, ok := query.Raycast(box, test.origin, test.dir, query.RaycastOptions{})
+		if !ok {
+			t.Errorf("%s: ray missed", test.name)
+			continue
+		}
+		if !d3.EqualWithin(hit.Position, test.wantPos, tol) || math.Abs(hit.Distance-test.wantDistance) > tol {
+			t.Errorf("%s: got hit at %v distance %g, want %v distance %g", test.name, hit.Position, hit.Distance, test.wantPos, test.wantDistance)
+		}
+		if !d3.EqualWithin(hit.Normal, test.wantNormal, tol) {
+			t.Errorf("%s: got normal %v, want %v", test.name, hit.Normal, test.wantNormal)
+		}
+		if hit.Steps <= 0 {
+			t.Errorf("%s: got %d steps", test.name, hit.Steps)
+		}
+	}
+	// Misses.
+	if _, ok := query.Raycast(box, r3.Vec{}, r3.Vec{Z: -1}, query.RaycastOptions{}); ok {
+		t.Error("ray pointing away from object hit")
+	}
+	if _, ok := query.Raycast(box, r3.Vec{X: 3}, r3.Vec{Z: 1}, query.RaycastOptions{}); ok {
+		t.Error("ray passing by object hit")
+	}
+	if _, ok := query.Raycast(box, r3.Vec{}, r3.Vec{Z: 1}, query.RaycastOptions{MaxDistance: 3}); ok {
+		t.Error("ray hit beyond MaxDistance")
+	}
+}
+
+func TestClosestPoint(t *testing.T) {
+	const tol = 1e-6
+	sphere := sdf.Transform3D(must3.Sphere(2), sdf.Translate3D(r3.Vec{X: 1, Y: 1, Z: 1}))
+	for _, p := range []r3.Vec{{X: 10, Y: 1, Z: 1}, {X: 1.5, Y: 1, Z: 1}, {X: -3, Y: 4, Z: 2}} {
+		got := query.ClosestPoint(sphere, p)
+		want := r3.Add(r3.Vec{X: 1, Y: 1, Z: 1}, r3.Scale(2, r3.Unit(r3.Sub(p, r3.Vec{X: 1, Y: 1, Z: 1}))))
+		if !d3.EqualWithin(got, want, tol) {
+			t.Errorf("closest point to %v: got %v, want %v", p, got, want)
+		}
+	}
+	box := must3.Box(r3.Vec{X: 2, Y: 4, Z: 6}, 0)
+	if got, want := query.ClosestPoint(box, r3.Vec{X: 5, Y: 0.5, Z: -1}), (r3.Vec{X: 1, Y: 0.5, Z: -1}); !d3.EqualWithin(got, want, tol) {
+		t.Errorf("closest point on box: got %v, want %v", got, want)
+	}
+	if got, want := query.Normal(box, r3.Vec{X: 0.5, Y: 3, Z: 0}), (r3.Vec{Y: 1}); !d3.EqualWithin(got, want, tol) {
+		t.Errorf("box normal: got %v, want %v", got, want)
+	}
+}
+
+func TestRaycast2D(t *testing.T) {
+	const tol = 1e-4
+	circle := sdf.Transform2D(must2.Circle(1), sdf.Translate2D(r2.Vec{X: 3}))
+	hit, ok := query.Raycast2D(circle, r2.Vec{}, r2.Vec{X: 1}, query.RaycastOptions{})
+	if !ok {
+		t.Fatal("ray missed")
+	}
+	if !d2.EqualWithin(hit.Position, r2.Vec{X: 2}, tol) || math.Abs(hit.Distance-2) > tol {
+		t.Errorf("got hit at %v distance %g", hit.Position, hit.Distance)
+	}
+	if !d2.EqualWithin(hit.Normal, r2.Vec{X: -1}, tol) {
+		t.Errorf("got normal %v", hit.Normal)
+	}
+	if _, ok := query.Raycast2D(circle, r2.Vec{}, r2.Vec{Y: 1}, query.RaycastOptions{}); ok {
+		t.Error("ray passing by circle hit")
+	}
+	got := query.ClosestPoint2D(circle, r2.Vec{X: 3, Y: -5})
+	if !d2.EqualWithin(got, r2.Vec{X: 3, Y: -1}, 1e-6) {
+		t.Errorf("closest point: got %v", got)
+	}
+	if n := query.Normal2D(circle, r2.Vec{X: 3, Y: 2}); !d2.EqualWithin(n, r2.Vec{Y: 1}, 1e-6) {
+		t.Errorf("normal: got %v", n)
+	}
+}
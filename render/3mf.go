@@ -3,9 +3,13 @@ package render
 import (
 	"archive/zip"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"image/color"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"gonum.org/v1/gonum/spatial/r3"
 )
@@ -24,23 +28,172 @@ var (
 	}
 )
 
+// ErrNonManifold is wrapped by the error returned when writing a 3MF file
+// containing a mesh that is not manifold. The file is written regardless
+// but may be rejected or repaired by the consuming software.
+var ErrNonManifold = errors.New("mesh not manifold")
+
 // Create3MF saves Renderer stream to a 3MF file type.
 // This function is in experimental stage and may not work
-// properly for large models.
+// properly for large models. See Create3MFParts for the errors returned.
 func Create3MF(filename string, r Renderer) error {
-	t, err := RenderAll(r)
+	return Create3MFParts(filename, []Part3MF{{Name: "SDFMesh", Renderer: r}})
+}
+
+// Part3MF is a named Renderer to be saved as a separate object
+// of a 3MF file by Create3MFParts.
+type Part3MF struct {
+	Name     string
+	Renderer Renderer
+	// Color is the display color of the part's base material.
+	// If nil no material is assigned to the part.
+	Color color.Color
+	// Transform is the build item transform of the part.
+	// The zero value is interpreted as the identity transform.
+	Transform Transform3MF
+}
+
+// Create3MFParts renders each part and saves them as separate objects of a
+// 3MF file so that slicers treat them as separate bodies.
+// If a part's mesh is not manifold the file is still written and an
+// error wrapping ErrNonManifold is returned.
+func Create3MFParts(filename string, parts []Part3MF) error {
+	objects := make([]Object3MF, len(parts))
+	for i, part := range parts {
+		t, err := RenderAll(part.Renderer)
+		if err != nil {
+			return fmt.Errorf("rendering part %q: %w", part.Name, err)
+		}
+		objects[i] = Object3MF{
+			Name:       part.Name,
+			Triangles:  t,
+			Color:      part.Color,
+			Transforms: []Transform3MF{part.Transform},
+		}
+	}
+	fp, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
+	defer fp.Close()
+	err = Write3MF(fp, objects)
+	if err != nil && !errors.Is(err, ErrNonManifold) {
+		return err
+	}
+	if cerr := fp.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// Object3MF is a mesh object of a 3MF file.
+type Object3MF struct {
+	Name string
+	// Triangles of the object's mesh in millimeters, before
+	// build item transforms are applied.
+	Triangles []r3.Triangle
+	// Color is the display color of the object's base material.
+	// It is nil if the object has no material assigned.
+	Color color.Color
+	// Transforms of the build items referencing the object. An object may
+	// be placed several times in the build. When writing a 3MF file an
+	// object with no transforms is placed once without transform.
+	Transforms []Transform3MF
+}
+
+// Write3MF writes objects to w in 3MF file format.
+// If an object's mesh is not manifold the file is still written and an
+// error wrapping ErrNonManifold is returned.
+func Write3MF(w io.Writer, objects []Object3MF) error {
+	if len(objects) == 0 {
+		return errors.New("no objects to write")
+	}
 	mf := new3MF([]modelMetadata{
 		{Name: "author", Description: "anonymous"},
 	})
-	err = mf.AddObject("SDFMesh", t)
+	var warning error
+	for _, obj := range objects {
+		if len(obj.Triangles) == 0 {
+			return fmt.Errorf("object %q has no triangles", obj.Name)
+		}
+		err := mf.AddObject(obj)
+		if err != nil && warning == nil {
+			warning = fmt.Errorf("object %q: %w", obj.Name, err)
+		}
+	}
+	err := mf.write(w)
+	if err != nil {
+		return err
+	}
+	return warning
+}
+
+// Read3MF reads the objects of a 3MF file. Object meshes are converted to
+// millimeters. Objects composed of other objects are returned with
+// the triangles of their components.
+func Read3MF(r io.ReaderAt, size int64) ([]Object3MF, error) {
+	zr, err := zip.NewReader(r, size)
 	if err != nil {
-		fmt.Println("file generated may be corrupt or not be manifold:", err)
+		return nil, err
+	}
+	var m model
+	err = decodeZipXML(zr, find3MFModel(zr), &m)
+	if err != nil {
+		return nil, err
+	}
+	return m.objects()
+}
+
+// Transform3MF is an affine transform stored in 3MF matrix layout. The
+// first nine elements are the rows of the linear part applied to
+// row vectors and the last three elements are the translation.
+type Transform3MF [12]float64
+
+// Identity3MF returns the identity transform.
+func Identity3MF() Transform3MF {
+	return Transform3MF{0: 1, 4: 1, 8: 1}
+}
+
+// Translate3MF returns a transform that translates by v.
+func Translate3MF(v r3.Vec) Transform3MF {
+	t := Identity3MF()
+	t[9], t[10], t[11] = v.X, v.Y, v.Z
+	return t
+}
+
+// Apply returns the point p transformed by t.
+func (t Transform3MF) Apply(p r3.Vec) r3.Vec {
+	return r3.Vec{
+		X: p.X*t[0] + p.Y*t[3] + p.Z*t[6] + t[9],
+		Y: p.X*t[1] + p.Y*t[4] + p.Z*t[7] + t[10],
+		Z: p.X*t[2] + p.Y*t[5] + p.Z*t[8] + t[11],
 	}
+}
 
-	return mf.Create(filename)
+// String returns the transform formatted as a 3MF transform attribute.
+func (t Transform3MF) String() string {
+	var b strings.Builder
+	for i, f := range t {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return b.String()
+}
+
+func parseTransform3MF(s string) (t Transform3MF, err error) {
+	fields := strings.Fields(s)
+	if len(fields) != len(t) {
+		return t, fmt.Errorf("3MF transform %q must have %d elements", s, len(t))
+	}
+	for i := range t {
+		t[i], err = strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return t, err
+		}
+	}
+	return t, nil
 }
 
 func new3MF(metadata []modelMetadata) model {
@@ -69,7 +222,19 @@ type model struct {
 }
 
 type resources struct {
-	Objects []mf3object `xml:"object"`
+	BaseMaterials []baseMaterials `xml:"basematerials"`
+	Objects       []mf3object     `xml:"object"`
+}
+
+type baseMaterials struct {
+	ID   int            `xml:"id,attr"`
+	Base []baseMaterial `xml:"base"`
+}
+
+type baseMaterial struct {
+	Name string `xml:"name,attr"`
+	// Display color in sRGB hex notation, i.e. #RRGGBB or #RRGGBBAA.
+	DisplayColor string `xml:"displaycolor,attr"`
 }
 
 type item struct {
@@ -83,13 +248,21 @@ type modelMetadata struct {
 }
 
 type mf3object struct {
-	ID         int     `xml:"id,attr"`
-	Name       string  `xml:"name,attr"`
-	PartNumber string  `xml:"partnumber,attr"`
-	PID        int     `xml:"pid,attr"`
-	PIndex     int     `xml:"pindex,attr"`
-	Type       string  `xml:"type,attr"`
-	Mesh       mf3Mesh `xml:"mesh"`
+	ID         int    `xml:"id,attr"`
+	Name       string `xml:"name,attr"`
+	PartNumber string `xml:"partnumber,attr"`
+	// PID references a base materials group. PIndex is
+	// required if PID is set and indexes a material of the group.
+	PID        int            `xml:"pid,attr,omitempty"`
+	PIndex     *int           `xml:"pindex,attr,omitempty"`
+	Type       string         `xml:"type,attr"`
+	Mesh       *mf3Mesh       `xml:"mesh,omitempty"`
+	Components []mf3Component `xml:"components>component,omitempty"`
+}
+
+type mf3Component struct {
+	ObjectID  int    `xml:"objectid,attr"`
+	Transform string `xml:"transform,attr,omitempty"`
 }
 
 type mf3Mesh struct {
@@ -109,73 +282,247 @@ type mf3Triangle struct {
 	V3 int `xml:"v3,attr"`
 }
 
-func (m *model) AddObject(name string, src []r3.Triangle) error {
-	id := len(m.Resources.Objects) + 1
-	type vertexEntry struct {
-		idx   int
-		count int
-	}
-	dst := make([]mf3Triangle, len(src))
-	vertexMap := make(map[r3.Vec]vertexEntry)
-	vertices := make([]mf3Vertex, 0, len(src)*3)
-	for it, t := range src {
-		for i := 0; i < 3; i++ {
-			vertex := t[i]
-			entry, ok := vertexMap[vertex]
-			if !ok {
-				entry = vertexEntry{
-					idx:   len(vertices),
-					count: 1,
+// AddObject adds obj to the model's resources and build. If the object's
+// mesh is not manifold the object is added and an error is returned.
+func (m *model) AddObject(obj Object3MF) error {
+	mobj := mf3object{
+		Name: obj.Name,
+		Type: "model",
+	}
+	if obj.Color != nil {
+		// All base materials are kept in a single group
+		// created when the first colored object is added.
+		if len(m.Resources.BaseMaterials) == 0 {
+			m.Resources.BaseMaterials = []baseMaterials{{ID: m.nextID()}}
+		}
+		group := &m.Resources.BaseMaterials[0]
+		pindex := len(group.Base)
+		group.Base = append(group.Base, baseMaterial{
+			Name:         obj.Name,
+			DisplayColor: hexColor(obj.Color),
+		})
+		mobj.PID = group.ID
+		mobj.PIndex = &pindex
+	}
+	mobj.ID = m.nextID()
+	mobj.PartNumber = obj.Name + "-" + strconv.Itoa(mobj.ID)
+	mesh, weldErr := weldMesh(obj.Triangles)
+	mobj.Mesh = &mesh
+	m.Resources.Objects = append(m.Resources.Objects, mobj)
+	transforms := obj.Transforms
+	if len(transforms) == 0 {
+		transforms = []Transform3MF{{}}
+	}
+	for _, t := range transforms {
+		it := item{ObjectID: mobj.ID}
+		if t != (Transform3MF{}) && t != Identity3MF() {
+			it.Transform = t.String()
+		}
+		m.Build = append(m.Build, it)
+	}
+	return weldErr
+}
+
+// nextID returns an unused resource ID.
+func (m *model) nextID() int {
+	return len(m.Resources.Objects) + len(m.Resources.BaseMaterials) + 1
+}
+
+// weldMesh joins the identical vertices of src to create an indexed mesh.
+// An error is returned if the resulting mesh is not manifold.
+func weldMesh(src []r3.Triangle) (mf3Mesh, error) {
+	m := weld(src)
+	mesh := mf3Mesh{
+		Vertices:  make([]mf3Vertex, len(m.Vertices)),
+		Triangles: make([]mf3Triangle, len(m.Triangles)),
+	}
+	count := make([]int, len(m.Vertices)) // number of triangles sharing each vertex.
+	for i, t := range m.Triangles {
+		mesh.Triangles[i] = mf3Triangle{V1: t[0], V2: t[1], V3: t[2]}
+		count[t[0]]++
+		count[t[1]]++
+		count[t[2]]++
+	}
+	for i, v := range m.Vertices {
+		mesh.Vertices[i] = mf3Vertex(v)
+	}
+	for i, c := range count {
+		if c < 3 {
+			return mesh, fmt.Errorf("%w: vertex %+v shared by less than 3 triangles", ErrNonManifold, m.Vertices[i])
+		}
+	}
+	return mesh, nil
+}
+
+// objects returns the model's objects with their meshes converted to millimeters.
+func (m *model) objects() ([]Object3MF, error) {
+	scale, ok := unitScale3MF[m.Unit]
+	if !ok && m.Unit != "" {
+		return nil, fmt.Errorf("unknown 3MF unit %q", m.Unit)
+	} else if !ok {
+		scale = 1 // Default unit is millimeter.
+	}
+	byID := make(map[int]int, len(m.Resources.Objects))
+	for i, obj := range m.Resources.Objects {
+		byID[obj.ID] = i
+	}
+	materials := make(map[int][]baseMaterial)
+	for _, group := range m.Resources.BaseMaterials {
+		materials[group.ID] = group.Base
+	}
+	// triangles resolves object meshes and components recursively.
+	var triangles func(id int, depth int) ([]r3.Triangle, error)
+	triangles = func(id int, depth int) ([]r3.Triangle, error) {
+		const maxDepth = 32
+		idx, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("3MF object id %d not found", id)
+		} else if depth > maxDepth {
+			return nil, errors.New("3MF component nesting too deep or cyclic")
+		}
+		obj := m.Resources.Objects[idx]
+		var out []r3.Triangle
+		if obj.Mesh != nil {
+			nv := len(obj.Mesh.Vertices)
+			for _, t := range obj.Mesh.Triangles {
+				if t.V1 < 0 || t.V2 < 0 || t.V3 < 0 || t.V1 >= nv || t.V2 >= nv || t.V3 >= nv {
+					return nil, fmt.Errorf("3MF object id %d: triangle vertex index out of range", id)
 				}
-				vertices = append(vertices, mf3Vertex(vertex))
-			} else {
-				entry.count++
+				out = append(out, r3.Triangle{
+					r3.Scale(scale, r3.Vec(obj.Mesh.Vertices[t.V1])),
+					r3.Scale(scale, r3.Vec(obj.Mesh.Vertices[t.V2])),
+					r3.Scale(scale, r3.Vec(obj.Mesh.Vertices[t.V3])),
+				})
 			}
-			vertexMap[vertex] = entry
-			switch i {
-			case 0:
-				dst[it].V1 = entry.idx
-			case 1:
-				dst[it].V2 = entry.idx
-			case 2:
-				dst[it].V3 = entry.idx
+		}
+		for _, c := range obj.Components {
+			ct, err := triangles(c.ObjectID, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if c.Transform != "" {
+				tf, err := parseTransform3MF(c.Transform)
+				if err != nil {
+					return nil, err
+				}
+				tf = scaleTranslation(tf, scale)
+				for i := range ct {
+					for j := range ct[i] {
+						ct[i][j] = tf.Apply(ct[i][j])
+					}
+				}
 			}
+			out = append(out, ct...)
 		}
+		return out, nil
 	}
 
-	m.Resources.Objects = append(m.Resources.Objects,
-		mf3object{
-			ID:         id,
-			Name:       name,
-			Type:       "model",
-			PartNumber: name + "-" + strconv.Itoa(id),
-			Mesh: mf3Mesh{
-				Vertices:  vertices,
-				Triangles: dst,
-			},
-		},
-	)
-	m.Build = append(m.Build, item{
-		ObjectID:  id,
-		Transform: "", // no transform
-	})
-	for vertex, data := range vertexMap {
-		if data.count < 3 {
-			return fmt.Errorf("vertex %+v shared by less than 3 triangles", vertex)
+	objects := make([]Object3MF, len(m.Resources.Objects))
+	for i, obj := range m.Resources.Objects {
+		t, err := triangles(obj.ID, 0)
+		if err != nil {
+			return nil, err
+		}
+		objects[i] = Object3MF{
+			Name:      obj.Name,
+			Triangles: t,
+		}
+		if base, ok := materials[obj.PID]; ok && obj.PIndex != nil && *obj.PIndex < len(base) && *obj.PIndex >= 0 {
+			objects[i].Color, err = parseHexColor(base[*obj.PIndex].DisplayColor)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, it := range m.Build {
+		idx, ok := byID[it.ObjectID]
+		if !ok {
+			return nil, fmt.Errorf("3MF build item references missing object id %d", it.ObjectID)
+		}
+		tf := Identity3MF()
+		if it.Transform != "" {
+			var err error
+			tf, err = parseTransform3MF(it.Transform)
+			if err != nil {
+				return nil, err
+			}
+			tf = scaleTranslation(tf, scale)
 		}
+		objects[idx].Transforms = append(objects[idx].Transforms, tf)
 	}
-	return nil
+	return objects, nil
 }
 
-func (m *model) Create(filename string) error {
-	fp, err := os.Create(filename)
+var unitScale3MF = map[string]float64{
+	"micron":       1e-3,
+	unitMillimeter: 1,
+	"centimeter":   10,
+	"inch":         25.4,
+	"foot":         304.8,
+	"meter":        1000,
+}
+
+func scaleTranslation(t Transform3MF, scale float64) Transform3MF {
+	t[9] *= scale
+	t[10] *= scale
+	t[11] *= scale
+	return t
+}
+
+func hexColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02X%02X%02X%02X", n.R, n.G, n.B, n.A)
+}
+
+func parseHexColor(s string) (color.NRGBA, error) {
+	c := color.NRGBA{A: 0xff}
+	if len(s) != 7 && len(s) != 9 || s[0] != '#' {
+		return c, fmt.Errorf("invalid 3MF color %q", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
 	if err != nil {
-		return err
+		return c, fmt.Errorf("invalid 3MF color %q: %w", s, err)
 	}
-	defer fp.Close()
-	w := zip.NewWriter(fp)
-	defer w.Close()
-	f, err := w.Create(modelFile)
+	if len(s) == 7 {
+		v = v<<8 | 0xff
+	}
+	c.R, c.G, c.B, c.A = uint8(v>>24), uint8(v>>16), uint8(v>>8), uint8(v)
+	return c, nil
+}
+
+// find3MFModel returns the name of the 3MF model part of zr.
+func find3MFModel(zr *zip.Reader) string {
+	var rels relationships
+	err := decodeZipXML(zr, "_rels/.rels", &rels)
+	if err == nil {
+		for _, rel := range rels.Relationship {
+			if rel.Type == xmlModelSchema {
+				return strings.TrimPrefix(rel.Target, "/")
+			}
+		}
+	}
+	return modelFile
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v interface{}) error {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return xml.NewDecoder(rc).Decode(v)
+	}
+	return fmt.Errorf("%q not found in 3MF file", name)
+}
+
+func (m *model) write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+	f, err := zw.Create(modelFile)
 	if err != nil {
 		return err
 	}
@@ -189,7 +536,7 @@ func (m *model) Create(filename string) error {
 	if err != nil {
 		return err
 	}
-	rels, err := w.Create("_rels/.rels")
+	rels, err := zw.Create("_rels/.rels")
 	if err != nil {
 		return err
 	}
@@ -203,7 +550,7 @@ func (m *model) Create(filename string) error {
 	if err != nil {
 		return err
 	}
-	ct, err := w.Create("[Content_Types].xml")
+	ct, err := zw.Create("[Content_Types].xml")
 	if err != nil {
 		return err
 	}
@@ -212,22 +559,25 @@ func (m *model) Create(filename string) error {
 		return err
 	}
 	// Make sure to check the error on Close.
-	return w.Close()
+	return zw.Close()
 }
 
-func (m *model) relationships() interface{} {
-	type Relationship struct {
-		Target string `xml:",attr"`
-		ID     string `xml:"Id,attr"`
-		Type   string `xml:",attr"`
-	}
-	type Relationships struct {
-		Schema       string         `xml:"xmlns,attr"`
-		Relationship []Relationship `xml:"Relationship"`
-	}
-	return Relationships{
+type relationships struct {
+	XMLName      xml.Name       `xml:"Relationships"`
+	Schema       string         `xml:"xmlns,attr"`
+	Relationship []relationship `xml:"Relationship"`
+}
+
+type relationship struct {
+	Target string `xml:",attr"`
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:",attr"`
+}
+
+func (m *model) relationships() relationships {
+	return relationships{
 		Schema: "http://schemas.openxmlformats.org/package/2006/relationships",
-		Relationship: []Relationship{
+		Relationship: []relationship{
 			{
 				Target: modelFile,
 				Type:   xmlModelSchema,
@@ -1,9 +1,12 @@
 package render_test
 
 import (
+	"errors"
+	"image/color"
 	"os"
 	"testing"
 
+	"github.com/soypat/sdf"
 	"github.com/soypat/sdf/form3"
 	"github.com/soypat/sdf/render"
 	"gonum.org/v1/gonum/spatial/r3"
@@ -18,3 +21,69 @@ func Test3MF(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func Test3MFPartsReadback(t *testing.T) {
+	const path = "assembly.3mf"
+	defer os.Remove(path)
+	support, _ := form3.Box(r3.Vec{X: 10, Y: 10, Z: 1}, .1)
+	standoff, _ := form3.Cylinder(4, 1, 0)
+	const quality = 40
+	shapes := []sdf.SDF3{support, standoff}
+	parts := []render.Part3MF{
+		{Name: "support", Renderer: render.NewOctreeRenderer(support, quality)},
+		{
+			Name:      "standoff",
+			Renderer:  render.NewOctreeRenderer(standoff, quality),
+			Color:     color.NRGBA{R: 0xff, G: 0x80, A: 0xff},
+			Transform: render.Translate3MF(r3.Vec{X: 4, Y: 4, Z: 2.5}),
+		},
+	}
+	err := render.Create3MFParts(path, parts)
+	if err != nil && !errors.Is(err, render.ErrNonManifold) {
+		t.Fatal(err)
+	}
+	fp, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	info, err := fp.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects, err := render.Read3MF(fp, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != len(parts) {
+		t.Fatalf("got %d objects, want %d", len(objects), len(parts))
+	}
+	for i, obj := range objects {
+		part := parts[i]
+		if obj.Name != part.Name {
+			t.Errorf("got object name %q, want %q", obj.Name, part.Name)
+		}
+		want, err := render.RenderAll(render.NewOctreeRenderer(shapes[i], quality))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(obj.Triangles) != len(want) {
+			t.Errorf("%s: got %d triangles, want %d", obj.Name, len(obj.Triangles), len(want))
+		}
+		if len(obj.Transforms) != 1 {
+			t.Fatalf("%s: got %d transforms, want 1", obj.Name, len(obj.Transforms))
+		}
+		wantTransform := part.Transform
+		if wantTransform == (render.Transform3MF{}) {
+			wantTransform = render.Identity3MF()
+		}
+		if obj.Transforms[0] != wantTransform {
+			t.Errorf("%s: got transform %v, want %v", obj.Name, obj.Transforms[0], wantTransform)
+		}
+		if part.Color == nil && obj.Color != nil {
+			t.Errorf("%s: expected no color, got %v", obj.Name, obj.Color)
+		} else if part.Color != nil && obj.Color != part.Color {
+			t.Errorf("%s: got color %v, want %v", obj.Name, obj.Color, part.Color)
+		}
+	}
+}
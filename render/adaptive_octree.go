@@ -0,0 +1,322 @@
+package render
+
+import (
+	"io"
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// adaptiveOctree renders using dual contouring on an octree which is only
+// subdivided where the surface is not well approximated by a plane.
+type adaptiveOctree struct {
+	dc  dc3
+	tol float64
+	// step used for gradient evaluation.
+	eps  float64
+	root *aoNode
+	// triangles generated that have not been read yet.
+	pending []r3.Triangle
+	done    bool
+}
+
+// aoNode is a node of the adaptive octree. Children and corners
+// are indexed as x*4 + y*2 + z where x, y and z are 0 for the
+// minimum and 1 for the maximum of the node's cube along each axis.
+type aoNode struct {
+	cube
+	// children is nil for leaf nodes. Children containing
+	// no surface are nil.
+	children *[8]*aoNode
+	// bit i set if corner i is inside the surface.
+	corners uint8
+	// dual vertex of leaf nodes.
+	vertex r3.Vec
+}
+
+// NewAdaptiveOctreeRenderer returns a dual contouring Renderer which samples
+// space with an adaptive octree. A cell stops being subdivided when the
+// SDF within it is approximated by a plane with an error smaller than
+// tolerance, so flat regions of the model are meshed with few large
+// triangles. meshCells sets the finest resolution, same as in NewOctreeRenderer.
+// The resulting mesh is crack-free even where cells of different sizes meet.
+//
+// Unlike the octree renderer the whole octree and all of the triangles are
+// built in memory on the first call to ReadTriangles, so memory use grows
+// with the size and detail of the model. Prefer NewOctreeRenderer for very
+// large models or fine resolutions.
+func NewAdaptiveOctreeRenderer(s sdf.SDF3, meshCells int, tolerance float64) *adaptiveOctree {
+	if meshCells < 2 {
+		panic("meshCells must be 2 or larger")
+	}
+	if tolerance <= 0 {
+		panic("tolerance must be positive")
+	}
+	// Scale the bounding box about the center to make sure the boundaries
+	// aren't on the object surface.
+	bb := d3.Box(s.Bounds())
+	bb = bb.ScaleAboutCenter(1.01)
+	longAxis := d3.Max(bb.Size())
+	// The smallest cube (n=1) has a side of 2*resolution,
+	// same as the octree marching cubes renderer.
+	resolution := 0.5 * longAxis / float64(meshCells)
+	levels := uint(math.Ceil(math.Log2(longAxis/resolution))) + 1
+	return &adaptiveOctree{
+		dc:  *newDc3(s, bb.Min, resolution, levels),
+		tol: tolerance,
+		eps: 1e-4 * resolution,
+		root: &aoNode{
+			cube: cube{sdf.V3i{0, 0, 0}, levels - 1},
+		},
+	}
+}
+
+// ReadTriangles writes triangles rendered from the model into the argument buffer.
+// returns number of triangles written and an error if present.
+func (ao *adaptiveOctree) ReadTriangles(dst []r3.Triangle) (n int, err error) {
+	if len(dst) == 0 {
+		panic("cannot write to empty triangle slice")
+	}
+	if !ao.done {
+		// The whole octree is needed to find the neighbors of
+		// each leaf so triangles are generated all at once.
+		ao.done = true
+		if ao.build(ao.root) {
+			ao.cellProc(ao.root)
+		}
+		// Release octree and cache memory.
+		ao.root = nil
+		ao.dc.cache = nil
+	}
+	if len(ao.pending) == 0 {
+		return 0, io.EOF
+	}
+	n = copy(dst, ao.pending)
+	ao.pending = ao.pending[n:]
+	return n, nil
+}
+
+// build subdivides the node until its cells are at the finest resolution or
+// the surface within is approximately planar. Returns false if the
+// node contains no surface.
+func (ao *adaptiveOctree) build(node *aoNode) bool {
+	if ao.dc.IsEmpty(&node.cube) {
+		return false
+	}
+	if node.n > 1 && !ao.isPlanar(node.cube) {
+		node.children = new([8]*aoNode)
+		n := node.n - 1
+		s := 1 << n
+		nonEmpty := false
+		for i := range node.children {
+			child := &aoNode{cube: cube{node.Add(sdf.V3i{s * (i >> 2), s * (i >> 1 & 1), s * (i & 1)}), n}}
+			if ao.build(child) {
+				node.children[i] = child
+				nonEmpty = true
+			}
+		}
+		return nonEmpty
+	}
+	// Leaf node.
+	var (
+		corners [8]r3.Vec
+		values  [8]float64
+	)
+	s := 1 << node.n
+	for i := range corners {
+		corners[i], values[i] = ao.dc.Evaluate(node.Add(sdf.V3i{s * (i >> 2), s * (i >> 1 & 1), s * (i & 1)}))
+		if values[i] < 0 {
+			node.corners |= 1 << i
+		}
+	}
+	var q qef
+	for _, e := range aoEdgeCorners {
+		va, vb := values[e[0]], values[e[1]]
+		if (va < 0) == (vb < 0) {
+			continue
+		}
+		p := r3.Add(corners[e[0]], r3.Scale(va/(va-vb), r3.Sub(corners[e[1]], corners[e[0]])))
+		q.add(p, gradient(ao.dc.s, p, ao.eps))
+	}
+	if q.count == 0 {
+		// Surface does not cross the cell's edges. Use the
+		// projection of the cell center on the surface.
+		center, d := ao.dc.Evaluate(node.AddScalar(s / 2))
+		node.vertex = d3.Clamp(r3.Sub(center, r3.Scale(d, gradient(ao.dc.s, center, ao.eps))), corners[0], corners[7])
+		return true
+	}
+	// Keep vertex within its cell to avoid self intersections.
+	node.vertex = d3.Clamp(q.solve(), corners[0], corners[7])
+	return true
+}
+
+// isPlanar returns true if the SDF sampled on a 3x3x3 grid over the cube
+// is approximated within tolerance by the linear extrapolation of the
+// distance and gradient at the cube center.
+func (ao *adaptiveOctree) isPlanar(c cube) bool {
+	h := 1 << (c.n - 1) // half side
+	center, d := ao.dc.Evaluate(c.AddScalar(h))
+	g := gradient(ao.dc.s, center, ao.eps)
+	for i := 0; i <= 2; i++ {
+		for j := 0; j <= 2; j++ {
+			for k := 0; k <= 2; k++ {
+				p, dp := ao.dc.Evaluate(c.Add(sdf.V3i{i * h, j * h, k * h}))
+				if math.Abs(dp-(d+r3.Dot(g, r3.Sub(p, center)))) > ao.tol {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// The following tables and the cellProc, faceProc and edgeProc recursion
+// follow the octree dual contouring method described in
+// "Dual Contouring of Hermite Data" by Ju et al.
+
+// aoEdgeCorners are the corner pairs forming each cell edge
+// grouped by direction.
+var aoEdgeCorners = [12][2]int{
+	{0, 4}, {1, 5}, {2, 6}, {3, 7}, // x
+	{0, 2}, {1, 3}, {4, 6}, {5, 7}, // y
+	{0, 1}, {2, 3}, {4, 5}, {6, 7}, // z
+}
+
+// Pairs of children sharing a face and the face direction.
+var aoCellFaces = [12][3]int{
+	{0, 4, 0}, {1, 5, 0}, {2, 6, 0}, {3, 7, 0},
+	{0, 2, 1}, {4, 6, 1}, {1, 3, 1}, {5, 7, 1},
+	{0, 1, 2}, {2, 3, 2}, {4, 5, 2}, {6, 7, 2},
+}
+
+// Groups of four children sharing an edge and the edge direction.
+var aoCellEdges = [6][5]int{
+	{0, 1, 2, 3, 0}, {4, 5, 6, 7, 0},
+	{0, 4, 1, 5, 1}, {2, 6, 3, 7, 1},
+	{0, 2, 4, 6, 2}, {1, 3, 5, 7, 2},
+}
+
+var aoFaceFaces = [3][4][3]int{
+	{{4, 0, 0}, {5, 1, 0}, {6, 2, 0}, {7, 3, 0}},
+	{{2, 0, 1}, {6, 4, 1}, {3, 1, 1}, {7, 5, 1}},
+	{{1, 0, 2}, {3, 2, 2}, {5, 4, 2}, {7, 6, 2}},
+}
+
+var aoFaceEdges = [3][4][6]int{
+	{{1, 4, 0, 5, 1, 1}, {1, 6, 2, 7, 3, 1}, {0, 4, 6, 0, 2, 2}, {0, 5, 7, 1, 3, 2}},
+	{{0, 2, 3, 0, 1, 0}, {0, 6, 7, 4, 5, 0}, {1, 2, 0, 6, 4, 2}, {1, 3, 1, 7, 5, 2}},
+	{{1, 1, 0, 3, 2, 0}, {1, 5, 4, 7, 6, 0}, {0, 1, 5, 0, 4, 1}, {0, 3, 7, 2, 6, 1}},
+}
+
+var aoFaceEdgeOrders = [2][4]int{{0, 0, 1, 1}, {0, 1, 0, 1}}
+
+var aoEdgeEdges = [3][2][5]int{
+	{{3, 2, 1, 0, 0}, {7, 6, 5, 4, 0}},
+	{{5, 1, 4, 0, 1}, {7, 3, 6, 2, 1}},
+	{{6, 4, 2, 0, 2}, {7, 5, 3, 1, 2}},
+}
+
+// Edge of each of the four cells surrounding an edge in a given direction.
+var aoProcessEdges = [3][4]int{{3, 2, 1, 0}, {7, 5, 6, 4}, {11, 10, 9, 8}}
+
+func (ao *adaptiveOctree) cellProc(node *aoNode) {
+	if node == nil || node.children == nil {
+		return
+	}
+	ch := node.children
+	for _, child := range ch {
+		ao.cellProc(child)
+	}
+	for _, f := range aoCellFaces {
+		ao.faceProc([2]*aoNode{ch[f[0]], ch[f[1]]}, f[2])
+	}
+	for _, e := range aoCellEdges {
+		ao.edgeProc([4]*aoNode{ch[e[0]], ch[e[1]], ch[e[2]], ch[e[3]]}, e[4])
+	}
+}
+
+func (ao *adaptiveOctree) faceProc(nodes [2]*aoNode, dir int) {
+	if nodes[0] == nil || nodes[1] == nil {
+		return
+	}
+	if nodes[0].children == nil && nodes[1].children == nil {
+		return
+	}
+	for _, f := range aoFaceFaces[dir] {
+		var faceNodes [2]*aoNode
+		for j := range faceNodes {
+			faceNodes[j] = nodes[j].child(f[j])
+		}
+		ao.faceProc(faceNodes, f[2])
+	}
+	for _, e := range aoFaceEdges[dir] {
+		order := aoFaceEdgeOrders[e[0]]
+		var edgeNodes [4]*aoNode
+		for j := range edgeNodes {
+			edgeNodes[j] = nodes[order[j]].child(e[j+1])
+		}
+		ao.edgeProc(edgeNodes, e[5])
+	}
+}
+
+func (ao *adaptiveOctree) edgeProc(nodes [4]*aoNode, dir int) {
+	leaves := true
+	for _, node := range nodes {
+		if node == nil {
+			return
+		}
+		leaves = leaves && node.children == nil
+	}
+	if leaves {
+		ao.processEdge(nodes, dir)
+		return
+	}
+	for _, e := range aoEdgeEdges[dir] {
+		var edgeNodes [4]*aoNode
+		for j := range edgeNodes {
+			edgeNodes[j] = nodes[j].child(e[j])
+		}
+		ao.edgeProc(edgeNodes, e[4])
+	}
+}
+
+// processEdge generates the quad surrounding the smallest edge shared by
+// four leaf nodes if the surface crosses it.
+func (ao *adaptiveOctree) processEdge(nodes [4]*aoNode, dir int) {
+	minIndex := 0
+	for i, node := range nodes {
+		if node.n < nodes[minIndex].n {
+			minIndex = i
+		}
+	}
+	e := aoEdgeCorners[aoProcessEdges[dir][minIndex]]
+	corners := nodes[minIndex].corners
+	in1 := corners>>e[0]&1 == 1
+	in2 := corners>>e[1]&1 == 1
+	if in1 == in2 {
+		return
+	}
+	v := [4]r3.Vec{nodes[0].vertex, nodes[1].vertex, nodes[2].vertex, nodes[3].vertex}
+	var tris [2]r3.Triangle
+	if in1 {
+		tris = [2]r3.Triangle{{v[0], v[3], v[1]}, {v[0], v[2], v[3]}}
+	} else {
+		tris = [2]r3.Triangle{{v[0], v[1], v[3]}, {v[0], v[3], v[2]}}
+	}
+	for _, t := range tris {
+		// Triangles of cells repeated around an edge are degenerate.
+		if t[0] != t[1] && t[1] != t[2] && t[2] != t[0] {
+			ao.pending = append(ao.pending, t)
+		}
+	}
+}
+
+// child returns the ith child of node or node itself if it is a leaf.
+func (node *aoNode) child(i int) *aoNode {
+	if node.children == nil {
+		return node
+	}
+	return node.children[i]
+}
@@ -0,0 +1,66 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/soypat/sdf"
+	form3 "github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestAdaptiveOctreeCrackFree(t *testing.T) {
+	const quality = 100
+	// Large flat panel with a small detailed feature.
+	panel := sdf.Union3D(
+		form3.Box(r3.Vec{X: 20, Y: 20, Z: 4}, 0),
+		sdf.Transform3D(form3.Sphere(1), sdf.Translate3D(r3.Vec{X: 4, Y: 4, Z: 2})),
+	)
+	adaptive, err := render.RenderAll(render.NewAdaptiveOctreeRenderer(panel, quality, 1e-3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uniform, err := render.RenderAll(render.NewOctreeRenderer(panel, quality))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(adaptive) == 0 {
+		t.Fatal("no triangles rendered")
+	}
+	if 4*len(adaptive) > len(uniform) {
+		t.Errorf("expected adaptive mesh to have far fewer triangles: adaptive %d, uniform %d", len(adaptive), len(uniform))
+	}
+	// A crack in the mesh leaves edges used by a single triangle.
+	type edge [2]r3.Vec
+	edges := make(map[edge]int)
+	for _, tri := range adaptive {
+		for i := range tri {
+			a, b := tri[i], tri[(i+1)%3]
+			if b.X < a.X || b.X == a.X && (b.Y < a.Y || b.Y == a.Y && b.Z < a.Z) {
+				a, b = b, a
+			}
+			edges[edge{a, b}]++
+		}
+	}
+	for e, count := range edges {
+		if count%2 != 0 {
+			t.Fatalf("edge %v used by %d triangles", e, count)
+		}
+	}
+}
+
+func TestAdaptiveOctreeNormals(t *testing.T) {
+	model, err := render.RenderAll(render.NewAdaptiveOctreeRenderer(form3.Sphere(1), 40, 1e-3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model) == 0 {
+		t.Fatal("no triangles rendered")
+	}
+	for i, tri := range model {
+		centroid := r3.Scale(1./3, r3.Add(tri[0], r3.Add(tri[1], tri[2])))
+		if r3.Dot(tri.Normal(), centroid) <= 0 {
+			t.Fatalf("triangle %d normal points inwards: %v", i, tri)
+		}
+	}
+}
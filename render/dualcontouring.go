@@ -0,0 +1,300 @@
+package render
+
+import (
+	"io"
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// dualContouring renders using dual contouring on a uniform grid.
+type dualContouring struct {
+	s      sdf.SDF3
+	origin r3.Vec
+	// side length of a cell.
+	size float64
+	// step used for gradient evaluation.
+	eps float64
+	// number of cells in each direction.
+	nx, ny, nz int
+	// next cell layer to process.
+	k int
+	// distances at point layers k and k+1.
+	d0, d1 []float64
+	// cell vertices of cell layers k-1 and k.
+	v0, v1 []r3.Vec
+	// triangles generated by the last processed layer
+	// that have not been read yet.
+	pending []r3.Triangle
+}
+
+// NewDualContouringRenderer returns a dual contouring Renderer. Unlike
+// marching cubes, vertices are placed inside each cell by minimizing a
+// quadratic error function built from the SDF gradient at the surface
+// crossings of the cell edges. This preserves sharp edges and corners
+// of the model which are otherwise bevelled.
+//
+// The model is processed one layer of cells at a time along the Z axis so
+// memory use is proportional to the cross section of the bounding box.
+func NewDualContouringRenderer(s sdf.SDF3, meshCells int) *dualContouring {
+	if meshCells < 2 {
+		panic("meshCells must be 2 or larger")
+	}
+	// Scale the bounding box about the center to make sure the boundaries
+	// aren't on the object surface.
+	bb := d3.Box(s.Bounds())
+	bb = bb.ScaleAboutCenter(1.01)
+	size := d3.Max(bb.Size()) / float64(meshCells)
+	cells := r3.Scale(1/size, bb.Size())
+	dc := &dualContouring{
+		s:      s,
+		origin: bb.Min,
+		size:   size,
+		eps:    1e-4 * size,
+		nx:     int(math.Ceil(cells.X)),
+		ny:     int(math.Ceil(cells.Y)),
+		nz:     int(math.Ceil(cells.Z)),
+	}
+	npoints := (dc.nx + 1) * (dc.ny + 1)
+	dc.d0 = make([]float64, npoints)
+	dc.d1 = make([]float64, npoints)
+	dc.v0 = make([]r3.Vec, dc.nx*dc.ny)
+	dc.v1 = make([]r3.Vec, dc.nx*dc.ny)
+	// First call to processLayer expects point layer 0 in d1.
+	dc.evaluateLayer(dc.d1, 0)
+	return dc
+}
+
+// ReadTriangles writes triangles rendered from the model into the argument buffer.
+// returns number of triangles written and an error if present.
+func (dc *dualContouring) ReadTriangles(dst []r3.Triangle) (n int, err error) {
+	if len(dst) == 0 {
+		panic("cannot write to empty triangle slice")
+	}
+	for n < len(dst) {
+		if len(dc.pending) == 0 {
+			if dc.k >= dc.nz {
+				if n == 0 {
+					return 0, io.EOF
+				}
+				break
+			}
+			dc.processLayer()
+			continue
+		}
+		nc := copy(dst[n:], dc.pending)
+		dc.pending = dc.pending[nc:]
+		n += nc
+	}
+	return n, nil
+}
+
+// pointIdx returns the index of a grid point within a point layer.
+func (dc *dualContouring) pointIdx(i, j int) int { return i + j*(dc.nx+1) }
+
+// cellIdx returns the index of a cell within a cell layer.
+func (dc *dualContouring) cellIdx(i, j int) int { return i + j*dc.nx }
+
+// point returns the position of grid point (i,j,k).
+func (dc *dualContouring) point(i, j, k int) r3.Vec {
+	return r3.Add(dc.origin, r3.Scale(dc.size, r3.Vec{X: float64(i), Y: float64(j), Z: float64(k)}))
+}
+
+// evaluateLayer stores the SDF distances of point layer k in dst.
+func (dc *dualContouring) evaluateLayer(dst []float64, k int) {
+	for j := 0; j <= dc.ny; j++ {
+		for i := 0; i <= dc.nx; i++ {
+			dst[dc.pointIdx(i, j)] = dc.s.Evaluate(dc.point(i, j, k))
+		}
+	}
+}
+
+// processLayer generates the triangles of the surface around the edges
+// of point layer k and the edges between point layers k and k+1.
+func (dc *dualContouring) processLayer() {
+	k := dc.k
+	dc.k++
+	dc.d0, dc.d1 = dc.d1, dc.d0
+	dc.evaluateLayer(dc.d1, k+1)
+	dc.v0, dc.v1 = dc.v1, dc.v0
+	for j := 0; j < dc.ny; j++ {
+		for i := 0; i < dc.nx; i++ {
+			dc.v1[dc.cellIdx(i, j)] = dc.cellVertex(i, j, k)
+		}
+	}
+	// Reuse pending slice which has been fully read.
+	tris := dc.pending[:0]
+	for j := 0; j <= dc.ny; j++ {
+		for i := 0; i <= dc.nx; i++ {
+			da := dc.d0[dc.pointIdx(i, j)]
+			// Edge along X in point layer k.
+			if i < dc.nx && j > 0 && j < dc.ny && k > 0 {
+				db := dc.d0[dc.pointIdx(i+1, j)]
+				if (da < 0) != (db < 0) {
+					tris = appendQuad(tris, da < 0,
+						dc.v0[dc.cellIdx(i, j-1)], dc.v0[dc.cellIdx(i, j)],
+						dc.v1[dc.cellIdx(i, j)], dc.v1[dc.cellIdx(i, j-1)])
+				}
+			}
+			// Edge along Y in point layer k.
+			if j < dc.ny && i > 0 && i < dc.nx && k > 0 {
+				db := dc.d0[dc.pointIdx(i, j+1)]
+				if (da < 0) != (db < 0) {
+					tris = appendQuad(tris, da < 0,
+						dc.v0[dc.cellIdx(i-1, j)], dc.v1[dc.cellIdx(i-1, j)],
+						dc.v1[dc.cellIdx(i, j)], dc.v0[dc.cellIdx(i, j)])
+				}
+			}
+			// Edge along Z between point layers k and k+1.
+			if i > 0 && i < dc.nx && j > 0 && j < dc.ny {
+				db := dc.d1[dc.pointIdx(i, j)]
+				if (da < 0) != (db < 0) {
+					tris = appendQuad(tris, da < 0,
+						dc.v1[dc.cellIdx(i-1, j-1)], dc.v1[dc.cellIdx(i, j-1)],
+						dc.v1[dc.cellIdx(i, j)], dc.v1[dc.cellIdx(i-1, j)])
+				}
+			}
+		}
+	}
+	dc.pending = tris
+}
+
+// appendQuad appends the two triangles of the quad formed by the cell
+// vertices surrounding an edge. The vertices are ordered counter-clockwise
+// around the edge direction and the winding is reversed if the
+// start of the edge lies outside the surface.
+func appendQuad(dst []r3.Triangle, startInside bool, a, b, c, d r3.Vec) []r3.Triangle {
+	if !startInside {
+		b, d = d, b
+	}
+	// Split along the shortest diagonal for better shaped triangles.
+	var t1, t2 r3.Triangle
+	if r3.Norm2(r3.Sub(c, a)) <= r3.Norm2(r3.Sub(d, b)) {
+		t1, t2 = r3.Triangle{a, b, c}, r3.Triangle{a, c, d}
+	} else {
+		t1, t2 = r3.Triangle{a, b, d}, r3.Triangle{b, c, d}
+	}
+	for _, t := range [2]r3.Triangle{t1, t2} {
+		if t[0] != t[1] && t[1] != t[2] && t[2] != t[0] {
+			dst = append(dst, t)
+		}
+	}
+	return dst
+}
+
+// dcEdges are the pairs of corners forming the edges of a cell.
+// Corner index bits correspond to offsets in x, y and z.
+var dcEdges = [12][2]int{
+	{0, 1}, {2, 3}, {4, 5}, {6, 7}, // x edges
+	{0, 2}, {1, 3}, {4, 6}, {5, 7}, // y edges
+	{0, 4}, {1, 5}, {2, 6}, {3, 7}, // z edges
+}
+
+// cellVertex returns the vertex of cell (i,j,k) which minimizes the
+// quadratic error function of the planes tangent to the surface at the
+// edge crossings. If the surface does not cross the cell the zero vector
+// is returned.
+func (dc *dualContouring) cellVertex(i, j, k int) r3.Vec {
+	var (
+		corners [8]r3.Vec
+		values  [8]float64
+	)
+	for c := range corners {
+		ci, cj, ck := c&1, (c>>1)&1, (c>>2)&1
+		corners[c] = dc.point(i+ci, j+cj, k+ck)
+		if ck == 0 {
+			values[c] = dc.d0[dc.pointIdx(i+ci, j+cj)]
+		} else {
+			values[c] = dc.d1[dc.pointIdx(i+ci, j+cj)]
+		}
+	}
+	var q qef
+	for _, e := range dcEdges {
+		va, vb := values[e[0]], values[e[1]]
+		if (va < 0) == (vb < 0) {
+			continue
+		}
+		p := r3.Add(corners[e[0]], r3.Scale(va/(va-vb), r3.Sub(corners[e[1]], corners[e[0]])))
+		q.add(p, gradient(dc.s, p, dc.eps))
+	}
+	if q.count == 0 {
+		return r3.Vec{}
+	}
+	// Keep vertex within its cell to avoid self intersections.
+	return d3.Clamp(q.solve(), corners[0], corners[7])
+}
+
+// qef accumulates the planes tangent to the surface at the edge
+// crossings of a cell to build a quadratic error function.
+type qef struct {
+	ata       [6]float64 // upper triangle of AᵀA
+	atb, mass r3.Vec
+	count     int
+}
+
+// add adds the plane through p with normal n.
+func (q *qef) add(p, n r3.Vec) {
+	q.ata[0] += n.X * n.X
+	q.ata[1] += n.X * n.Y
+	q.ata[2] += n.X * n.Z
+	q.ata[3] += n.Y * n.Y
+	q.ata[4] += n.Y * n.Z
+	q.ata[5] += n.Z * n.Z
+	q.atb = r3.Add(q.atb, r3.Scale(r3.Dot(n, p), n))
+	q.mass = r3.Add(q.mass, p)
+	q.count++
+}
+
+// solve returns the point minimizing the quadratic error function.
+// At least one plane must have been added.
+func (q *qef) solve() r3.Vec {
+	mass := r3.Scale(1/float64(q.count), q.mass)
+	return r3.Add(mass, solveQEF(q.ata, q.atb, mass))
+}
+
+// solveQEF returns the displacement x from the mass point that minimizes
+// |A(mass+x) - b|² using the pseudo-inverse of AᵀA. Small eigenvalues are
+// truncated so underdetermined systems, such as cells crossed by a flat
+// surface, keep the vertex close to the mass point.
+func solveQEF(ata [6]float64, atb, mass r3.Vec) r3.Vec {
+	const truncate = 0.1
+	a := mat.NewSymDense(3, []float64{
+		ata[0], ata[1], ata[2],
+		ata[1], ata[3], ata[4],
+		ata[2], ata[4], ata[5],
+	})
+	// Right hand side relative to mass point.
+	var am mat.VecDense
+	am.MulVec(a, mat.NewVecDense(3, []float64{mass.X, mass.Y, mass.Z}))
+	rhs := r3.Sub(atb, r3.Vec{X: am.AtVec(0), Y: am.AtVec(1), Z: am.AtVec(2)})
+	var eig mat.EigenSym
+	if !eig.Factorize(a, true) {
+		return r3.Vec{}
+	}
+	values := eig.Values(nil)
+	var vectors mat.Dense
+	eig.VectorsTo(&vectors)
+	maxValue := math.Max(values[0], math.Max(values[1], values[2]))
+	var x r3.Vec
+	for i, lambda := range values {
+		if lambda <= truncate*maxValue {
+			continue
+		}
+		u := r3.Vec{X: vectors.At(0, i), Y: vectors.At(1, i), Z: vectors.At(2, i)}
+		x = r3.Add(x, r3.Scale(r3.Dot(u, rhs)/lambda, u))
+	}
+	return x
+}
+
+// gradient returns the normalized gradient of s at p
+// calculated with central differences.
+func gradient(s sdf.SDF3, p r3.Vec, eps float64) r3.Vec {
+	return r3.Unit(r3.Vec{
+		X: s.Evaluate(r3.Add(p, r3.Vec{X: eps})) - s.Evaluate(r3.Add(p, r3.Vec{X: -eps})),
+		Y: s.Evaluate(r3.Add(p, r3.Vec{Y: eps})) - s.Evaluate(r3.Add(p, r3.Vec{Y: -eps})),
+		Z: s.Evaluate(r3.Add(p, r3.Vec{Z: eps})) - s.Evaluate(r3.Add(p, r3.Vec{Z: -eps})),
+	})
+}
@@ -0,0 +1,50 @@
+package render_test
+
+import (
+	"testing"
+
+	form3 "github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/internal/d3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestDualContouringNormals(t *testing.T) {
+	model, err := render.RenderAll(render.NewDualContouringRenderer(form3.Sphere(1), 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model) == 0 {
+		t.Fatal("no triangles rendered")
+	}
+	for i, tri := range model {
+		centroid := r3.Scale(1./3, r3.Add(tri[0], r3.Add(tri[1], tri[2])))
+		if r3.Dot(tri.Normal(), centroid) <= 0 {
+			t.Fatalf("triangle %d normal points inwards: %v", i, tri)
+		}
+	}
+}
+
+func TestDualContouringSharpCorners(t *testing.T) {
+	size := r3.Vec{X: 1, Y: 2, Z: 1.5}
+	model, err := render.RenderAll(render.NewDualContouringRenderer(form3.Box(size, 0), 40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Marching cubes bevels corners while dual contouring
+	// should place a vertex on each corner of the box.
+	tol := 1e-3 * d3.Max(size)
+	for _, corner := range d3.CenteredBox(r3.Vec{}, size).Vertices() {
+		found := false
+		for _, tri := range model {
+			for _, v := range tri {
+				if d3.EqualWithin(v, corner, tol) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			t.Errorf("no vertex found at box corner %v", corner)
+		}
+	}
+}
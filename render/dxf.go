@@ -0,0 +1,62 @@
+package render
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+
+	"github.com/yofu/dxf"
+	"github.com/yofu/dxf/insunit"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// CreateDXF renders an SDF2 as a DXF file using a Renderer2.
+// Outlines are written as LWPOLYLINE entities in millimetres.
+func CreateDXF(path string, r Renderer2) error {
+	lines, err := RenderAllLines(r)
+	if err != nil {
+		return err
+	}
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	err = WriteDXF(fp, lines)
+	if err != nil {
+		return err
+	}
+	return fp.Close()
+}
+
+// WriteDXF writes line segments to a writer as an AutoCAD 2000 (AC1015)
+// DXF drawing. Segments that share end points are joined into LWPOLYLINE
+// entities which are flagged as closed when the contour is closed.
+// Units are millimetres.
+func WriteDXF(w io.Writer, lines [][2]r2.Vec) error {
+	if len(lines) == 0 {
+		return errors.New("empty line slice")
+	}
+	d := dxf.NewDrawing()
+	h := d.Header()
+	h.InsUnit = insunit.Millimeters
+	min := r2.Vec{X: math.Inf(1), Y: math.Inf(1)}
+	max := r2.Vec{X: math.Inf(-1), Y: math.Inf(-1)}
+	for _, pl := range joinLines(lines) {
+		vertices := make([][]float64, len(pl.vertices))
+		for i, v := range pl.vertices {
+			vertices[i] = []float64{v.X, v.Y}
+			min = r2.Vec{X: math.Min(min.X, v.X), Y: math.Min(min.Y, v.Y)}
+			max = r2.Vec{X: math.Max(max.X, v.X), Y: math.Max(max.Y, v.Y)}
+		}
+		_, err := d.LwPolyline(pl.closed, vertices...)
+		if err != nil {
+			return err
+		}
+	}
+	h.ExtMin = []float64{min.X, min.Y, 0}
+	h.ExtMax = []float64{max.X, max.Y, 0}
+	_, err := d.WriteTo(w)
+	return err
+}
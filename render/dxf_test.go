@@ -0,0 +1,84 @@
+package render_test
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/soypat/sdf"
+	form2 "github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/render"
+	"github.com/yofu/dxf"
+	"github.com/yofu/dxf/entity"
+	"github.com/yofu/dxf/insunit"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestDXFClosedLoops(t *testing.T) {
+	const quality = 100
+	s := sdf.Difference2D(form2.Box(r2.Vec{X: 4, Y: 3}, 0.5), form2.Circle(1))
+	lines, err := render.RenderAllLines(render.NewQuadRenderer(s, quality))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	err = render.WriteDXF(&b, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Check the drawing has every section an AC1015 file requires.
+	var sections []string
+	scan := bufio.NewScanner(bytes.NewReader(b.Bytes()))
+	for scan.Scan() {
+		code := strings.TrimSpace(scan.Text())
+		if !scan.Scan() {
+			t.Fatal("odd number of lines in DXF output")
+		}
+		if code == "2" && len(sections) > 0 && sections[len(sections)-1] == "" {
+			sections[len(sections)-1] = scan.Text()
+		} else if code == "0" && scan.Text() == "SECTION" {
+			sections = append(sections, "")
+		}
+	}
+	want := []string{"HEADER", "CLASSES", "TABLES", "BLOCKS", "ENTITIES", "OBJECTS"}
+	if strings.Join(sections, " ") != strings.Join(want, " ") {
+		t.Errorf("got sections %v, want %v", sections, want)
+	}
+
+	d, err := dxf.FromReader(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := d.Header(); h.Version != "AC1015" || h.InsUnit != insunit.Millimeters {
+		t.Errorf("got version %s in %s, want AC1015 in millimetres", h.Version, h.InsUnit)
+	}
+	polylines, closed, vertices := 0, 0, 0
+	for _, e := range d.Entities() {
+		pl, ok := e.(*entity.LwPolyline)
+		if !ok {
+			t.Fatalf("unexpected entity %T", e)
+		}
+		polylines++
+		if pl.Closed {
+			closed++
+		}
+		vertices += pl.Num
+	}
+	if polylines != 2 || closed != 2 {
+		t.Errorf("expected 2 closed polylines (outline and hole), got %d polylines, %d closed", polylines, closed)
+	}
+	if vertices != len(lines) {
+		t.Errorf("expected one vertex per line segment in closed loops, got %d vertices for %d lines", vertices, len(lines))
+	}
+}
+
+func TestCreateDXF(t *testing.T) {
+	const path = "circle.dxf"
+	defer os.Remove(path)
+	err := render.CreateDXF(path, render.NewQuadRenderer(form2.Circle(10), 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
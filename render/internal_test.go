@@ -2,6 +2,7 @@ package render
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"runtime"
@@ -76,27 +77,95 @@ func TestSTLWriteReadback(t *testing.T) {
 }
 
 func TestOctreeMultithread(t *testing.T) {
-	oct := NewOctreeRenderer(must3.Sphere(20), 100)
-	oct.concurrent = 2
-	buf := make([]r3.Triangle, oct.concurrent*100)
-	var err error
-	var nt int
-	var model []r3.Triangle
+	s := must3.Sphere(20)
+	want, err := RenderAll(NewOctreeRenderer(s, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, deterministic := range []bool{false, true} {
+		opts := []OctreeOption{OctreeWorkers(4)}
+		if deterministic {
+			opts = append(opts, OctreeDeterministic())
+		}
+		oct := NewOctreeRenderer(s, 100, opts...)
+		buf := make([]r3.Triangle, 100)
+		var nt int
+		var model []r3.Triangle
+		err = nil
+		for err == nil {
+			nt, err = oct.ReadTriangles(buf)
+			model = append(model, buf[:nt]...)
+		}
+		if err != io.EOF {
+			t.Fatal(err)
+		}
+		if len(model) != oct.triangles || len(model) != len(want) {
+			t.Fatalf("triangles lost. got %d. octree read %d, single threaded %d", len(model), oct.triangles, len(want))
+		}
+		if oct.cubes != oct.cubesP {
+			t.Errorf("number of non empty cubes found %d must match number of cubes processed %d", oct.cubes, oct.cubesP)
+		}
+		if !deterministic {
+			continue
+		}
+		for i := range want {
+			if model[i] != want[i] {
+				t.Fatalf("deterministic output differs from single threaded at triangle %d", i)
+			}
+		}
+	}
+}
+
+func TestOctreeContextCancel(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		ctx, cancel := context.WithCancel(context.Background())
+		oct := NewOctreeRenderer(must3.Sphere(20), 100, OctreeWorkers(workers), OctreeContext(ctx))
+		buf := make([]r3.Triangle, 100)
+		_, err := oct.ReadTriangles(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		_, err = oct.ReadTriangles(buf)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("workers=%d: expected context cancelled error, got %v", workers, err)
+		}
+	}
+}
+
+func TestOctreeBoundedMemory(t *testing.T) {
+	const quality = 100
+	s := must3.Sphere(20)
+	want, err := RenderAll(NewOctreeRenderer(s, quality))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oc := NewOctreeRenderer(s, quality, OctreeCacheLimit(1<<14))
+	maxTodo := cap(oc.todo)
+	buf := make([]r3.Triangle, 100)
+	var got []r3.Triangle
 	for err == nil {
-		nt, err = oct.ReadTriangles(buf)
-		model = append(model, buf[:nt]...)
+		var nt int
+		nt, err = oc.ReadTriangles(buf)
+		got = append(got, buf[:nt]...)
+		if len(oc.todo) > maxTodo {
+			t.Fatalf("todo stack length %d exceeds bound %d", len(oc.todo), maxTodo)
+		}
+		if len(oc.dc.cache) > oc.dc.maxEntries {
+			t.Fatalf("distance cache length %d exceeds limit %d", len(oc.dc.cache), oc.dc.maxEntries)
+		}
 	}
 	if err != io.EOF {
 		t.Fatal(err)
 	}
-	if len(model) != oct.triangles {
-		t.Errorf("triangles lost. got %d. octree read %d", len(model), oct.triangles)
+	if len(got) != len(want) {
+		t.Fatalf("cache limit changed output: got %d triangles, want %d", len(got), len(want))
 	}
-	if oct.cubes != oct.cubesP {
-		t.Errorf("number of non empty cubes found %d must match number of cubes processed %d", oct.cubes, oct.cubesP)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("cache limit changed %dth triangle: got %v, want %v", i, got[i], want[i])
+		}
 	}
-	t.Log(oct.triangles)
-
 }
 
 func BenchmarkBoltThreaded(b *testing.B) {
@@ -112,8 +181,7 @@ func BenchmarkBoltThreaded(b *testing.B) {
 	})
 
 	for i := 0; i < b.N; i++ {
-		oct := NewOctreeRenderer(object, 300)
-		oct.concurrent = runtime.NumCPU()
+		oct := NewOctreeRenderer(object, 300, OctreeWorkers(runtime.NumCPU()))
 		CreateSTL(output, oct)
 	}
 }
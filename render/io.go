@@ -3,6 +3,7 @@ package render
 import (
 	"io"
 
+	"gonum.org/v1/gonum/spatial/r2"
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
@@ -44,3 +45,42 @@ func (b *TriangleBuffer) Write(t []r3.Triangle) int {
 }
 
 func (b *TriangleBuffer) Len() int { return len(b.buf) }
+
+// RenderAllLines reads the full contents of a Renderer2 and returns the slice read.
+// It does not return error on io.EOF, like the io.RenderAll implementation.
+func RenderAllLines(r Renderer2) ([][2]r2.Vec, error) {
+	var err error
+	var nl int
+	result := make([][2]r2.Vec, 0, 1<<10)
+	buf := make([][2]r2.Vec, 1024)
+	for {
+		nl, err = r.ReadLines(buf)
+		result = append(result, buf[:nl]...)
+		if err != nil {
+			break
+		}
+	}
+	if err == io.EOF {
+		return result, nil
+	}
+	return result, err
+}
+
+type LineBuffer struct {
+	buf [][2]r2.Vec
+}
+
+// Read reads from this buffer.
+func (b *LineBuffer) Read(l [][2]r2.Vec) int {
+	n := copy(l, b.buf)
+	b.buf = b.buf[n:]
+	return n
+}
+
+// Write appends lines to this buffer.
+func (b *LineBuffer) Write(l [][2]r2.Vec) int {
+	b.buf = append(b.buf, l...)
+	return len(l)
+}
+
+func (b *LineBuffer) Len() int { return len(b.buf) }
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// max number of line segments that can be formed from a single square.
+const marchingSquaresMaxLines = 2
+
+// msToLines writes the line segments found in a square to dst. Corners p
+// are ordered counter-clockwise starting at the bottom left corner and
+// center is the value at the middle of the square, used to resolve
+// ambiguous (saddle) cases. Segments are oriented with the inside of
+// the contour to their left.
+func msToLines(dst [][2]r2.Vec, p [4]r2.Vec, v [4]float64, center, x float64) (n int) {
+	if len(dst) < marchingSquaresMaxLines {
+		panic("destination line buffer must be greater than 2")
+	}
+	// Walking the square counter-clockwise an edge is an exit if
+	// we go from an inside corner to an outside corner and an entry
+	// if we go from the outside to the inside. The contour leaves
+	// the square's inside region at an exit and comes back at an entry.
+	var exits, entries [2]int
+	var nexit, nentry int
+	for i := 0; i < 4; i++ {
+		in0 := v[i] < x
+		in1 := v[(i+1)%4] < x
+		switch {
+		case in0 && !in1:
+			exits[nexit] = i
+			nexit++
+		case !in0 && in1:
+			entries[nentry] = i
+			nentry++
+		}
+	}
+	if nexit == 0 {
+		return 0
+	}
+	if nexit == 1 {
+		dst[0] = [2]r2.Vec{msEdgePoint(exits[0], p, v, x), msEdgePoint(entries[0], p, v, x)}
+		return 1
+	}
+	// Saddle: two opposing corners inside. If the center is inside the two
+	// inside corners are connected and each exit pairs with the entry that
+	// follows it. Otherwise each exit pairs with the entry that precedes it.
+	for i := 0; i < 2; i++ {
+		exit := exits[i]
+		var entry int
+		if center < x {
+			entry = (exit + 1) % 4
+		} else {
+			entry = (exit + 3) % 4
+		}
+		dst[i] = [2]r2.Vec{msEdgePoint(exit, p, v, x), msEdgePoint(entry, p, v, x)}
+	}
+	return 2
+}
+
+// msEdgePoint returns the interpolated point on edge i of a square.
+// Edges are interpolated from the lower to the higher grid coordinate so that
+// neighboring squares that share an edge yield the exact same point.
+func msEdgePoint(i int, p [4]r2.Vec, v [4]float64, x float64) r2.Vec {
+	a, b := msPairTable[i][0], msPairTable[i][1]
+	return msInterpolate(p[a], p[b], v[a], v[b], x)
+}
+
+// msInterpolate returns the point between p1 and p2 where the linearly
+// interpolated value equals x.
+func msInterpolate(p1, p2 r2.Vec, v1, v2, x float64) r2.Vec {
+	closeToV1 := math.Abs(x-v1) < marchingCubesEpsilon
+	closeToV2 := math.Abs(x-v2) < marchingCubesEpsilon
+	if closeToV1 && !closeToV2 {
+		return p1
+	}
+	if closeToV2 && !closeToV1 {
+		return p2
+	}
+	var t float64
+	if closeToV1 && closeToV2 {
+		// Pick the half way point
+		t = 0.5
+	} else {
+		// linear interpolation
+		t = (x - v1) / (v2 - v1)
+	}
+	return r2.Vec{
+		X: p1.X + t*(p2.X-p1.X),
+		Y: p1.Y + t*(p2.Y-p1.Y),
+	}
+}
+
+// Corner pairs for the square edges, lower grid coordinate first.
+// Corners are numbered counter-clockwise from the bottom left:
+//
+//	3 --- 2
+//	|     |
+//	0 --- 1
+var msPairTable = [4][2]int{
+	{0, 1}, // 0 bottom
+	{1, 2}, // 1 right
+	{3, 2}, // 2 top
+	{0, 3}, // 3 left
+}
@@ -0,0 +1,125 @@
+package render
+
+import (
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Mesh is an indexed triangle mesh. Triangles index into Vertices
+// and are wound counter-clockwise when viewed from outside the model.
+type Mesh struct {
+	Vertices []r3.Vec
+	// Normals contains a unit normal per vertex. It is nil if
+	// normals have not been computed.
+	Normals   []r3.Vec
+	Triangles [][3]int
+}
+
+// NewMesh reads all triangles from a Renderer and joins
+// identical vertices to create an indexed mesh.
+func NewMesh(r Renderer) (Mesh, error) {
+	t, err := RenderAll(r)
+	if err != nil {
+		return Mesh{}, err
+	}
+	return weld(t), nil
+}
+
+// ComputeNormals sets the mesh vertex normals to the normalized
+// gradient of s, which should be the SDF the mesh was rendered from.
+// The gradient is calculated by central differences.
+func (m *Mesh) ComputeNormals(s sdf.SDF3) {
+	size := r3.Norm(d3.Box(s.Bounds()).Size())
+	eps := 1e-6 * size
+	m.Normals = make([]r3.Vec, len(m.Vertices))
+	for i, p := range m.Vertices {
+		n := gradient(s, p, eps)
+		if math.IsNaN(n.X) || math.IsNaN(n.Y) || math.IsNaN(n.Z) {
+			// Gradient is zero or not defined, fall back to triangle normals.
+			continue
+		}
+		m.Normals[i] = n
+	}
+	// Average the normals of the triangles sharing a vertex
+	// where the gradient could not be calculated.
+	var fallback []r3.Vec
+	for _, t := range m.Triangles {
+		for _, vi := range t {
+			if m.Normals[vi] != (r3.Vec{}) {
+				continue
+			}
+			if fallback == nil {
+				fallback = make([]r3.Vec, len(m.Vertices))
+			}
+			tri := r3.Triangle{m.Vertices[t[0]], m.Vertices[t[1]], m.Vertices[t[2]]}
+			fallback[vi] = r3.Add(fallback[vi], tri.Normal())
+		}
+	}
+	for i, n := range fallback {
+		if n != (r3.Vec{}) {
+			m.Normals[i] = r3.Unit(n)
+		}
+	}
+}
+
+// weld joins vertices of the triangles closer than a small tolerance
+// relative to the model size to create an indexed mesh. A tolerance is
+// needed since renderers may calculate a vertex shared by neighboring
+// cells with differing floating point rounding.
+func weld(src []r3.Triangle) Mesh {
+	m := Mesh{
+		Vertices:  make([]r3.Vec, 0, len(src)/2),
+		Triangles: make([][3]int, len(src)),
+	}
+	if len(src) == 0 {
+		return m
+	}
+	bb := d3.Box{Min: src[0][0], Max: src[0][0]}
+	for _, t := range src {
+		for _, v := range t {
+			bb = bb.Include(v)
+		}
+	}
+	tol := 1e-9 * r3.Norm(bb.Size())
+	if tol == 0 {
+		tol = 1e-9
+	}
+	// Vertices are hashed to a grid of tolerance sized cells
+	// and searched for in the neighboring cells.
+	type key [3]int64
+	grid := make(map[key][]int)
+	keyOf := func(v r3.Vec) key {
+		return key{int64(math.Floor(v.X / tol)), int64(math.Floor(v.Y / tol)), int64(math.Floor(v.Z / tol))}
+	}
+	find := func(v r3.Vec) int {
+		k := keyOf(v)
+		for i := int64(-1); i <= 1; i++ {
+			for j := int64(-1); j <= 1; j++ {
+				for l := int64(-1); l <= 1; l++ {
+					for _, idx := range grid[key{k[0] + i, k[1] + j, k[2] + l}] {
+						if d3.EqualWithin(v, m.Vertices[idx], tol) {
+							return idx
+						}
+					}
+				}
+			}
+		}
+		return -1
+	}
+	for it, t := range src {
+		for i, vertex := range t {
+			idx := find(vertex)
+			if idx < 0 {
+				idx = len(m.Vertices)
+				k := keyOf(vertex)
+				grid[k] = append(grid[k], idx)
+				m.Vertices = append(m.Vertices, vertex)
+			}
+			m.Triangles[it][i] = idx
+		}
+	}
+	return m
+}
@@ -0,0 +1,108 @@
+package render_test
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	form3 "github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/internal/d3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestMeshWeld(t *testing.T) {
+	const radius = 5
+	s := form3.Sphere(radius)
+	tris, err := render.RenderAll(render.NewOctreeRenderer(s, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := render.NewMesh(render.NewOctreeRenderer(s, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Triangles) != len(tris) {
+		t.Fatalf("got %d triangles, want %d", len(m.Triangles), len(tris))
+	}
+	for i, tri := range m.Triangles {
+		for j, vi := range tri {
+			if !d3.EqualWithin(m.Vertices[vi], tris[i][j], 1e-9) {
+				t.Fatalf("%dth triangle vertex mismatch", i)
+			}
+		}
+	}
+	// Closed mesh with sphere topology: V - E + F = 2 where E = 3F/2.
+	euler := len(m.Vertices) - 3*len(m.Triangles)/2 + len(m.Triangles)
+	if euler != 2 {
+		t.Errorf("expected Euler characteristic 2, got %d (V=%d, F=%d)", euler, len(m.Vertices), len(m.Triangles))
+	}
+
+	m.ComputeNormals(s)
+	for i, n := range m.Normals {
+		if math.Abs(r3.Norm(n)-1) > 1e-9 {
+			t.Fatalf("normal %d not unit length: %v", i, n)
+		}
+		// Sphere normals point away from the center.
+		if r3.Cos(n, m.Vertices[i]) < 0.999 {
+			t.Fatalf("normal %d not radial: %v at %v", i, n, m.Vertices[i])
+		}
+	}
+}
+
+func TestMeshWriters(t *testing.T) {
+	m, err := render.NewMesh(render.NewOctreeRenderer(form3.Box(r3.Vec{X: 1, Y: 2, Z: 3}, 0.1), 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nv, nf := len(m.Vertices), len(m.Triangles)
+	for _, withNormals := range []bool{false, true} {
+		if withNormals {
+			m.ComputeNormals(form3.Box(r3.Vec{X: 1, Y: 2, Z: 3}, 0.1))
+		}
+		var b bytes.Buffer
+		err = render.WriteOBJ(&b, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := map[string]int{}
+		sc := bufio.NewScanner(&b)
+		for sc.Scan() {
+			fields := strings.Fields(sc.Text())
+			counts[fields[0]]++
+			if fields[0] == "f" && withNormals && !strings.Contains(fields[1], "//") {
+				t.Fatal("expected face normal indices in OBJ")
+			}
+		}
+		if counts["v"] != nv || counts["f"] != nf || (withNormals && counts["vn"] != nv) {
+			t.Errorf("OBJ element count mismatch: %v", counts)
+		}
+
+		b.Reset()
+		err = render.WritePLY(&b, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := b.String()[:strings.Index(b.String(), "end_header\n")+len("end_header\n")]
+		vertexSize := 12
+		if withNormals {
+			vertexSize = 24
+		}
+		if want := len(header) + nv*vertexSize + nf*13; b.Len() != want {
+			t.Errorf("binary PLY size mismatch: got %d, want %d", b.Len(), want)
+		}
+
+		b.Reset()
+		err = render.WritePLYASCII(&b, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		headerLines := strings.Count(header, "\n")
+		if len(lines) != headerLines+nv+nf {
+			t.Errorf("ASCII PLY line count mismatch: got %d, want %d", len(lines), headerLines+nv+nf)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+// CreateOBJ renders an SDF3 as a Wavefront OBJ file using a Renderer.
+// Identical vertices are joined so the file contains an indexed mesh.
+func CreateOBJ(path string, r Renderer) error {
+	m, err := NewMesh(r)
+	if err != nil {
+		return err
+	}
+	return createMeshFile(path, m, WriteOBJ)
+}
+
+// WriteOBJ writes an indexed mesh to a writer in Wavefront OBJ file format.
+// Vertex normals are written if present in the mesh.
+func WriteOBJ(w io.Writer, m Mesh) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("# " + libMetadata.Description + "\n")
+	for _, v := range m.Vertices {
+		bw.WriteString("v " + formatVec(v.X, v.Y, v.Z) + "\n")
+	}
+	for _, n := range m.Normals {
+		bw.WriteString("vn " + formatVec(n.X, n.Y, n.Z) + "\n")
+	}
+	hasNormals := len(m.Normals) > 0
+	for _, t := range m.Triangles {
+		bw.WriteString("f")
+		for _, vi := range t {
+			// OBJ indices start at 1.
+			idx := strconv.Itoa(vi + 1)
+			bw.WriteString(" " + idx)
+			if hasNormals {
+				bw.WriteString("//" + idx)
+			}
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+func formatVec(x, y, z float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64) + " " +
+		strconv.FormatFloat(y, 'g', -1, 64) + " " +
+		strconv.FormatFloat(z, 'g', -1, 64)
+}
+
+func createMeshFile(path string, m Mesh, write func(io.Writer, Mesh) error) error {
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	err = write(fp, m)
+	if err != nil {
+		return err
+	}
+	return fp.Close()
+}
+
+// validate checks the mesh is not empty and its indices are within range.
+func (m Mesh) validate() error {
+	if len(m.Triangles) == 0 {
+		return errors.New("empty mesh")
+	}
+	if m.Normals != nil && len(m.Normals) != len(m.Vertices) {
+		return errors.New("mesh normal and vertex count mismatch")
+	}
+	for _, t := range m.Triangles {
+		for _, vi := range t {
+			if vi < 0 || vi >= len(m.Vertices) {
+				return errors.New("mesh triangle vertex index out of range")
+			}
+		}
+	}
+	return nil
+}
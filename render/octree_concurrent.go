@@ -0,0 +1,208 @@
+package render
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// octreePipeline distributes octree subtrees among worker goroutines.
+// Subtrees are numbered in depth first order so their triangles can be
+// output in the same order as single threaded rendering.
+type octreePipeline struct {
+	cancel  context.CancelFunc
+	results chan octreeResult
+	// tokens limits the number of subtrees dispatched
+	// whose triangles have not yet been read.
+	tokens chan struct{}
+	// pending holds results received ahead of time in deterministic mode.
+	pending map[int][]r3.Triangle
+	next    int
+	done    bool
+}
+
+type octreeJob struct {
+	seq int
+	c   cube
+}
+
+type octreeResult struct {
+	seq  int
+	tris []r3.Triangle
+}
+
+// octreeJobLevels is the number of levels under the root cube at which
+// the octree is split into subtrees which are processed by workers.
+const octreeJobLevels = 4
+
+// readTrianglesConcurrent is the concurrent implementation of ReadTriangles.
+func (oc *octree) readTrianglesConcurrent(dst []r3.Triangle) (n int, err error) {
+	if oc.pipe == nil {
+		oc.startPipeline()
+	}
+	p := oc.pipe
+	for n < len(dst) {
+		if oc.unwritten.Len() > 0 {
+			n += oc.unwritten.Read(dst[n:])
+			continue
+		}
+		if p.done {
+			break
+		}
+		tris, ok := p.receive(oc.deterministic, n == 0)
+		if !ok {
+			// No triangles ready and some have been written.
+			break
+		}
+		oc.unwritten.Write(tris)
+	}
+	if err := oc.ctx.Err(); err != nil {
+		return n, err
+	}
+	if n == 0 && p.done {
+		p.cancel()
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// receive returns the triangles of the next subtree. If block is false
+// and no triangles are ready it returns false. When all results have
+// been received it sets done.
+func (p *octreePipeline) receive(deterministic, block bool) ([]r3.Triangle, bool) {
+	for {
+		if deterministic {
+			if tris, ok := p.pending[p.next]; ok {
+				delete(p.pending, p.next)
+				p.next++
+				<-p.tokens
+				return tris, true
+			}
+		}
+		var (
+			res octreeResult
+			ok  bool
+		)
+		if block {
+			res, ok = <-p.results
+		} else {
+			select {
+			case res, ok = <-p.results:
+			default:
+				return nil, false
+			}
+		}
+		if !ok {
+			// All workers finished. In deterministic mode all results
+			// are in order so pending must be empty at this point
+			// unless the context was cancelled.
+			p.done = true
+			return nil, false
+		}
+		if !deterministic {
+			<-p.tokens
+			return res.tris, true
+		}
+		p.pending[res.seq] = res.tris
+	}
+}
+
+// startPipeline starts the goroutine splitting the octree into subtrees and
+// the workers rendering them.
+func (oc *octree) startPipeline() {
+	ctx, cancel := context.WithCancel(oc.ctx)
+	p := &octreePipeline{
+		cancel:  cancel,
+		results: make(chan octreeResult, oc.workers),
+		tokens:  make(chan struct{}, 2*oc.workers),
+		pending: make(map[int][]r3.Triangle),
+	}
+	oc.pipe = p
+	jobs := make(chan octreeJob)
+	levels := uint(len(oc.dc.hdiag))
+	jobLevel := uint(1)
+	if levels > octreeJobLevels+1 {
+		jobLevel = levels - 1 - octreeJobLevels
+	}
+	// Split todo stack into subtrees keeping depth first ordering.
+	todo := oc.todo
+	oc.todo = nil
+	go func() {
+		defer close(jobs)
+		seq := 0
+		for len(todo) > 0 {
+			c := todo[len(todo)-1]
+			todo = todo[:len(todo)-1]
+			if c.n > jobLevel {
+				_, subCubes := oc.processCube(&oc.dc, nil, c)
+				oc.count(0, len(subCubes), 1)
+				for i := len(subCubes) - 1; i >= 0; i-- {
+					todo = append(todo, subCubes[i])
+				}
+				continue
+			}
+			select {
+			case p.tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- octreeJob{seq: seq, c: c}:
+			case <-ctx.Done():
+				return
+			}
+			seq++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	// Distance cache memory limit is shared among workers.
+	maxEntries := oc.dc.maxEntries / oc.workers
+	if oc.dc.maxEntries > 0 && maxEntries == 0 {
+		maxEntries = 1
+	}
+	for i := 0; i < oc.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each worker uses its own cache to avoid lock contention.
+			dc := newDc3(oc.dc.s, oc.dc.origin, oc.dc.resolution, uint(len(oc.dc.hdiag)))
+			dc.maxEntries = maxEntries
+			var tmp [marchingCubesMaxTriangles]r3.Triangle
+			for job := range jobs {
+				var (
+					tris             []r3.Triangle
+					found, processed int
+				)
+				stack := []cube{job.c}
+				for len(stack) > 0 {
+					const cancelCheckPeriod = 1 << 12
+					if processed%cancelCheckPeriod == 0 && ctx.Err() != nil {
+						return
+					}
+					c := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					nt, subCubes := oc.processCube(dc, tmp[:], c)
+					tris = append(tris, tmp[:nt]...)
+					found += len(subCubes)
+					processed++
+					for i := len(subCubes) - 1; i >= 0; i-- {
+						stack = append(stack, subCubes[i])
+					}
+				}
+				oc.count(len(tris), found, processed)
+				select {
+				case p.results <- octreeResult{seq: job.seq, tris: tris}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.results)
+	}()
+}
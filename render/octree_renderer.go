@@ -1,6 +1,7 @@
 package render
 
 import (
+	"context"
 	"io"
 	"math"
 	"sync"
@@ -12,12 +13,20 @@ import (
 
 // MarchingCubesOctree renders using marching cubes with octree space sampling.
 type octree struct {
-	dc        dc3
-	mu        sync.Mutex
+	dc dc3
+	mu sync.Mutex
+	// todo is the stack of cubes pending processing. Cubes are
+	// processed depth first so its length is bounded by 7*levels+1.
 	todo      []cube
 	unwritten TriangleBuffer
-	// concurrent goroutine processing.
-	concurrent int
+	// ctx cancels rendering when done.
+	ctx context.Context
+	// number of goroutines processing cubes.
+	workers int
+	// output triangles in single threaded order when rendering concurrently.
+	deterministic bool
+	// pipeline of concurrent workers. Started on first call to ReadTriangles.
+	pipe *octreePipeline
 	// Number of triangles generated.
 	triangles int
 	// number of non empty cubes found.
@@ -31,12 +40,63 @@ type cube struct {
 	n       uint // level of cube, size = 1 << n
 }
 
+// OctreeOption configures the octree renderer returned by NewOctreeRenderer.
+type OctreeOption func(*octree)
+
+// defaultCacheLimit is the default memory limit of the octree distance cache.
+const defaultCacheLimit = 256 << 20
+
+// OctreeCacheLimit sets the approximate amount of memory in bytes the
+// octree renderer's distance cache may use. When the limit is reached the
+// cache is cleared. Since cubes are processed depth first, neighboring
+// cubes are processed close in time and clearing the cache has little
+// impact on performance. A limit of zero or less removes the limit.
+// The default limit is 256MB.
+func OctreeCacheLimit(bytes int) OctreeOption {
+	return func(oc *octree) {
+		if bytes <= 0 {
+			oc.dc.maxEntries = 0
+			return
+		}
+		oc.dc.maxEntries = max(1, bytes/dc3EntrySize)
+	}
+}
+
+// OctreeWorkers sets the number of goroutines rendering cubes concurrently.
+// The default is a single goroutine. Use OctreeDeterministic to get the
+// same triangle ordering as single threaded rendering.
+func OctreeWorkers(n int) OctreeOption {
+	return func(oc *octree) {
+		oc.workers = max(1, n)
+	}
+}
+
+// OctreeDeterministic makes a concurrent octree renderer output triangles in
+// the same order as a single threaded renderer, at the cost of buffering
+// triangles rendered ahead of time. It has no effect on single threaded rendering.
+func OctreeDeterministic() OctreeOption {
+	return func(oc *octree) {
+		oc.deterministic = true
+	}
+}
+
+// OctreeContext sets the context of the renderer. Once the context is
+// cancelled ReadTriangles returns the context's error and any goroutines
+// started by the renderer exit. Consumers that stop reading triangles before
+// io.EOF is returned should cancel the context to release resources.
+func OctreeContext(ctx context.Context) OctreeOption {
+	return func(oc *octree) {
+		oc.ctx = ctx
+	}
+}
+
 // NewOctreeRenderer returns a Marching Cubes implementation using octree
-// cube sampling. As of May 2022 this implementation leaks the todo cube slice
-// which may impact performance for complex shapes rendered at high resolutions.
-// This is because it is much faster this way and simpler. Ideally the todo slice
-// should be a queue or circular buffer.
-func NewOctreeRenderer(s sdf.SDF3, meshCells int) *octree {
+// cube sampling. Cubes are processed depth first so memory used to keep track
+// of pending cubes is proportional to the octree depth. The memory used by the
+// distance cache can be limited with the OctreeCacheLimit option.
+// Concurrency and cancellation are configured with the OctreeWorkers,
+// OctreeDeterministic and OctreeContext options.
+func NewOctreeRenderer(s sdf.SDF3, meshCells int, opts ...OctreeOption) *octree {
 	if meshCells < 2 {
 		panic("meshCells must bw 2 or larger")
 	}
@@ -52,19 +112,22 @@ func NewOctreeRenderer(s sdf.SDF3, meshCells int) *octree {
 	// how many cube levels for the octree?
 	levels := uint(math.Ceil(math.Log2(longAxis/resolution))) + 1
 
-	// Calculate theoretical max amount of cubes
-	divisions := r3.Scale(1/resolution, bb.Size())
-	maxCubes := int(divisions.X) * int(divisions.Y) * int(divisions.Z)
-
-	// Allocate a reasonable size for cube slice
-	cubes := make([]cube, 1, max(1, maxCubes/64))
+	// Each processed cube pushes at most 8 cubes and pops one.
+	cubes := make([]cube, 1, 7*levels+1)
 	cubes[0] = cube{sdf.V3i{0, 0, 0}, levels - 1} // process the octree, start at the top level
-	return &octree{
+	oc := &octree{
 		dc:        *newDc3(s, bb.Min, resolution, levels),
 		unwritten: TriangleBuffer{buf: make([]r3.Triangle, 0, 1024)},
 		todo:      cubes,
 		cubes:     1,
+		ctx:       context.Background(),
+		workers:   1,
 	}
+	oc.dc.maxEntries = defaultCacheLimit / dc3EntrySize
+	for _, opt := range opts {
+		opt(oc)
+	}
+	return oc
 }
 
 // ReadTriangles writes triangles rendered from the model into the argument buffer.
@@ -73,6 +136,12 @@ func (oc *octree) ReadTriangles(dst []r3.Triangle) (n int, err error) {
 	if len(dst) == 0 {
 		panic("cannot write to empty triangle slice")
 	}
+	if err := oc.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if oc.workers > 1 {
+		return oc.readTrianglesConcurrent(dst)
+	}
 	if oc.unwritten.Len() > 0 {
 		n += oc.unwritten.Read(dst[n:])
 		if n == len(dst) {
@@ -83,117 +152,59 @@ func (oc *octree) ReadTriangles(dst []r3.Triangle) (n int, err error) {
 		// Done rendering model.
 		return n, io.EOF
 	}
-	// Number of additional triangles proccessed.
 	var nt int
-	if oc.concurrent < 1 || len(oc.todo) < oc.concurrent || len(dst) < oc.concurrent {
-		tproc, nc, newCubes := oc.readTriangles(dst[n:], oc.todo)
-		oc.todo = append(oc.todo, newCubes...)
-		oc.todo = oc.todo[nc:] // this leaks, luckily this is a short lived function?
-		// oc.todo = append(newCubes, oc.todo[cubesProcessed:]...) // Non leaking slow implementation
-		nt = tproc
-	} else {
-		nt = oc.readTrianglesThreaded(dst[n:])
-	}
+	nt, oc.todo = oc.readTriangles(dst[n:], oc.todo)
 	n += nt
-	return n, err
+	return n, oc.ctx.Err()
 }
 
 // readTriangles is single threaded implementation of ReadTriangles.
-// todo is the slice of cubes that shall be proccessed. n is the number of triangles written to dst.
-// Returned cubesProcessed is the number of cubes of todo that were completely processed.
-// Returned newCubes are non-empty cubes that should be processed in future calls to readTriangles.
+// Cubes are popped from the todo stack and processed depth first until dst
+// is full or the stack is empty. n is the number of triangles written to dst
+// and remaining is what is left of the todo stack.
 // Triangles that were not succesfully written to dst are stored in octree unwritten buffer.
-// This function is safe to call concurrently.
-func (oc *octree) readTriangles(dst []r3.Triangle, todo []cube) (n, cubesProcessed int, newCubes []cube) {
-	for _, cube := range todo {
-		if n == len(dst) {
-			// Finished writing all the buffer
+func (oc *octree) readTriangles(dst []r3.Triangle, todo []cube) (n int, remaining []cube) {
+	const cancelCheckPeriod = 1 << 12
+	for processed := 1; len(todo) > 0 && n < len(dst); processed++ {
+		if processed%cancelCheckPeriod == 0 && oc.ctx.Err() != nil {
 			break
 		}
-		if n+marchingCubesMaxTriangles > len(dst) {
+		c := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		if c.n == 1 && n+marchingCubesMaxTriangles > len(dst) {
 			// Not enough room in buffer to write all triangles that could be found by marching cubes.
-			tmp := make([]r3.Triangle, marchingCubesMaxTriangles)
-			tri, cubes := oc.processCube(tmp, cube)
-			oc.mu.Lock()
-			oc.unwritten.Write(tmp[:tri])
-			oc.mu.Unlock()
-			newCubes = append(newCubes, cubes...)
-			cubesProcessed++
+			var tmp [marchingCubesMaxTriangles]r3.Triangle
+			tri, _ := oc.processCube(&oc.dc, tmp[:], c)
+			oc.count(tri, 0, 1)
+			nc := copy(dst[n:], tmp[:tri])
+			n += nc
+			oc.unwritten.Write(tmp[nc:tri])
 			break
 		}
-		tri, cubes := oc.processCube(dst[n:], cube)
-		newCubes = append(newCubes, cubes...)
-		cubesProcessed++
+		tri, subCubes := oc.processCube(&oc.dc, dst[n:], c)
+		oc.count(tri, len(subCubes), 1)
 		n += tri
-	}
-	return n, cubesProcessed, newCubes
-}
-
-// readTrianglesThreaded is a multithreaded triangle reader implementation for octree.
-// It writes nt triangles into dst.
-func (oc *octree) readTrianglesThreaded(dst []r3.Triangle) (nt int) {
-	var wg sync.WaitGroup
-	div := len(dst) / oc.concurrent
-	work := make([][]r3.Triangle, oc.concurrent)
-	cubeWork := make([][]cube, oc.concurrent)
-	newCubesC := make([][]cube, oc.concurrent)
-	divC := len(oc.todo) / oc.concurrent
-	for i := 0; i < oc.concurrent; i++ {
-		i := i // Escape loop variable.
-		wg.Add(1)
-		go func() {
-			start := div * i
-			if i == oc.concurrent-1 {
-				work[i] = dst[start:]
-				cubeWork[i] = oc.todo[i*divC:]
-			} else {
-				work[i] = dst[start : start+div]
-				cubeWork[i] = oc.todo[i*divC : (i+1)*divC]
-			}
-			ntc, nc, newC := oc.readTriangles(work[i], cubeWork[i])
-			newCubesC[i] = newC
-			work[i] = work[i][:ntc]
-			cubeWork[i] = cubeWork[i][nc:]
-			wg.Done()
-		}()
-	}
-	wg.Wait()
-	// Consolidate work done.
-	offset := 0
-	oc.todo = oc.todo[len(oc.todo):]
-	for i := 0; i < oc.concurrent; i++ {
-		// Triangles written.
-		start := div*i - offset
-		if i != oc.concurrent-1 && len(work[i]) != div {
-			copy(dst[start+len(work[i]):], dst[start+div:])
-			offset += div - len(work[i])
-		}
-		nt += len(work[i])
-		// Cubes unprocessed.
-		if len(cubeWork[i]) != 0 {
-			oc.todo = append(oc.todo, cubeWork[i]...)
-		}
-		// New Cubes
-		if len(newCubesC[i]) != 0 {
-			oc.todo = append(oc.todo, newCubesC[i]...)
+		// Push in reverse order so first sub cube is processed first.
+		for i := len(subCubes) - 1; i >= 0; i-- {
+			todo = append(todo, subCubes[i])
 		}
 	}
-	return nt
+	return n, todo
 }
 
-// Process a cube. Generate triangles, or more cubes.
-// Safe to call concurrently.
-func (oc *octree) processCube(dst []r3.Triangle, c cube) (writtenTriangles int, newCubes []cube) {
+// Process a cube using dc to evaluate the SDF. Generate triangles, or more cubes.
+// Safe to call concurrently. Callers should update the octree statistics with count.
+func (oc *octree) processCube(dc *dc3, dst []r3.Triangle, c cube) (writtenTriangles int, newCubes []cube) {
 	if c.n == 1 {
 		// this cube is at the required resolution
-		c0, d0 := oc.dc.Evaluate(c.Add(sdf.V3i{0, 0, 0}))
-		c1, d1 := oc.dc.Evaluate(c.Add(sdf.V3i{2, 0, 0}))
-		c2, d2 := oc.dc.Evaluate(c.Add(sdf.V3i{2, 2, 0}))
-		c3, d3 := oc.dc.Evaluate(c.Add(sdf.V3i{0, 2, 0}))
-		c4, d4 := oc.dc.Evaluate(c.Add(sdf.V3i{0, 0, 2}))
-		c5, d5 := oc.dc.Evaluate(c.Add(sdf.V3i{2, 0, 2}))
-		c6, d6 := oc.dc.Evaluate(c.Add(sdf.V3i{2, 2, 2}))
-		c7, d7 := oc.dc.Evaluate(c.Add(sdf.V3i{0, 2, 2}))
+		c0, d0 := dc.Evaluate(c.Add(sdf.V3i{0, 0, 0}))
+		c1, d1 := dc.Evaluate(c.Add(sdf.V3i{2, 0, 0}))
+		c2, d2 := dc.Evaluate(c.Add(sdf.V3i{2, 2, 0}))
+		c3, d3 := dc.Evaluate(c.Add(sdf.V3i{0, 2, 0}))
+		c4, d4 := dc.Evaluate(c.Add(sdf.V3i{0, 0, 2}))
+		c5, d5 := dc.Evaluate(c.Add(sdf.V3i{2, 0, 2}))
+		c6, d6 := dc.Evaluate(c.Add(sdf.V3i{2, 2, 2}))
+		c7, d7 := dc.Evaluate(c.Add(sdf.V3i{0, 2, 2}))
 		corners := [8]r3.Vec{c0, c1, c2, c3, c4, c5, c6, c7}
 		values := [8]float64{d0, d1, d2, d3, d4, d5, d6, d7}
 		// output the triangle(s) for this cube
@@ -214,17 +225,21 @@ func (oc *octree) processCube(dst []r3.Triangle, c cube) (writtenTriangles int,
 		}
 		// Eliminate empty cubes.
 		for _, candidate := range subCubes {
-			if !oc.dc.IsEmpty(&candidate) {
+			if !dc.IsEmpty(&candidate) {
 				newCubes = append(newCubes, candidate)
 			}
 		}
 	}
+	return writtenTriangles, newCubes
+}
+
+// count adds to the octree statistics. Safe to call concurrently.
+func (oc *octree) count(triangles, cubesFound, cubesProcessed int) {
 	oc.mu.Lock()
-	oc.triangles += writtenTriangles
-	oc.cubes += len(newCubes)
-	oc.cubesP++
+	oc.triangles += triangles
+	oc.cubes += cubesFound
+	oc.cubesP += cubesProcessed
 	oc.mu.Unlock()
-	return writtenTriangles, newCubes
 }
 
 // dc3 implements a 3 dimensional distance cache. evaluates the SDF3 via a distance cache to avoid repeated evaluations.
@@ -237,8 +252,13 @@ type dc3 struct {
 	resolution float64             // size of smallest octree cube
 	hdiag      []float64           // lookup table of cube half diagonals
 	s          sdf.SDF3            // the SDF3 to be rendered
+	maxEntries int                 // cache is cleared when this size is reached. Zero means no limit.
 }
 
+// dc3EntrySize is the approximate memory used by a dc3 cache entry including map
+// overhead. The map may transiently use more memory while it grows.
+const dc3EntrySize = 96
+
 // Evaluate evaluates if
 func (dc *dc3) Evaluate(vi sdf.V3i) (r3.Vec, float64) {
 	v := r3.Add(dc.origin, r3.Scale(dc.resolution, vi.ToV3()))
@@ -297,6 +317,9 @@ func (dc *dc3) read(vi sdf.V3i) (float64, bool) {
 // write to the cache
 func (dc *dc3) write(vi sdf.V3i, dist float64) {
 	dc.mu.Lock()
+	if dc.maxEntries > 0 && len(dc.cache) >= dc.maxEntries {
+		dc.cache = make(map[sdf.V3i]float64)
+	}
 	dc.cache[vi] = dist
 	dc.mu.Unlock()
 }
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+// CreatePLY renders an SDF3 as a binary little-endian PLY file using a
+// Renderer. Identical vertices are joined so the file contains an indexed mesh.
+func CreatePLY(path string, r Renderer) error {
+	m, err := NewMesh(r)
+	if err != nil {
+		return err
+	}
+	return createMeshFile(path, m, WritePLY)
+}
+
+// WritePLY writes an indexed mesh to a writer in binary little-endian
+// PLY file format. Vertex normals are written if present in the mesh.
+func WritePLY(w io.Writer, m Mesh) error {
+	if err := validatePLY(m); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	writePLYHeader(bw, m, "binary_little_endian")
+	var buf [13]byte
+	for i, v := range m.Vertices {
+		put3F32(buf[:], [3]float32{float32(v.X), float32(v.Y), float32(v.Z)})
+		bw.Write(buf[:12])
+		if m.Normals != nil {
+			n := m.Normals[i]
+			put3F32(buf[:], [3]float32{float32(n.X), float32(n.Y), float32(n.Z)})
+			bw.Write(buf[:12])
+		}
+	}
+	for _, t := range m.Triangles {
+		buf[0] = 3
+		binary.LittleEndian.PutUint32(buf[1:], uint32(t[0]))
+		binary.LittleEndian.PutUint32(buf[5:], uint32(t[1]))
+		binary.LittleEndian.PutUint32(buf[9:], uint32(t[2]))
+		bw.Write(buf[:13])
+	}
+	return bw.Flush()
+}
+
+// WritePLYASCII writes an indexed mesh to a writer in ASCII PLY file format.
+// Vertex normals are written if present in the mesh.
+func WritePLYASCII(w io.Writer, m Mesh) error {
+	if err := validatePLY(m); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	writePLYHeader(bw, m, "ascii")
+	for i, v := range m.Vertices {
+		bw.WriteString(formatVec(v.X, v.Y, v.Z))
+		if m.Normals != nil {
+			n := m.Normals[i]
+			bw.WriteString(" " + formatVec(n.X, n.Y, n.Z))
+		}
+		bw.WriteByte('\n')
+	}
+	for _, t := range m.Triangles {
+		bw.WriteString("3 " + strconv.Itoa(t[0]) + " " + strconv.Itoa(t[1]) + " " + strconv.Itoa(t[2]) + "\n")
+	}
+	return bw.Flush()
+}
+
+func writePLYHeader(w *bufio.Writer, m Mesh, format string) {
+	w.WriteString("ply\nformat " + format + " 1.0\n")
+	w.WriteString("comment " + libMetadata.Description + "\n")
+	w.WriteString("element vertex " + strconv.Itoa(len(m.Vertices)) + "\n")
+	w.WriteString("property float x\nproperty float y\nproperty float z\n")
+	if m.Normals != nil {
+		w.WriteString("property float nx\nproperty float ny\nproperty float nz\n")
+	}
+	w.WriteString("element face " + strconv.Itoa(len(m.Triangles)) + "\n")
+	w.WriteString("property list uchar int vertex_indices\nend_header\n")
+}
+
+func validatePLY(m Mesh) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	if int64(len(m.Vertices)) > math.MaxInt32 {
+		return errors.New("mesh vertex count exceeds PLY int index limits")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// polyline is a chain of connected line segments.
+type polyline struct {
+	vertices []r2.Vec
+	// closed is true when the last vertex connects to the first vertex.
+	// The first vertex is not repeated at the end of vertices.
+	closed bool
+}
+
+// joinLines chains line segments that share end points into polylines.
+// Segments are expected to be oriented as Renderer2 outputs them so
+// chains are followed from segment end to segment start. Shared points
+// must be exactly equal, which is the case for segments generated by
+// a Renderer2 such as the one returned by NewQuadRenderer.
+func joinLines(lines [][2]r2.Vec) []polyline {
+	// Index segments by their start and end points.
+	starts := make(map[r2.Vec][]int, len(lines))
+	ends := make(map[r2.Vec][]int, len(lines))
+	for i, l := range lines {
+		if l[0] == l[1] {
+			continue // degenerate segment.
+		}
+		starts[l[0]] = append(starts[l[0]], i)
+		ends[l[1]] = append(ends[l[1]], i)
+	}
+	used := make([]bool, len(lines))
+	next := func(index map[r2.Vec][]int, v r2.Vec) (int, bool) {
+		for _, i := range index[v] {
+			if !used[i] {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+	var polylines []polyline
+	for i, l := range lines {
+		if used[i] || l[0] == l[1] {
+			continue
+		}
+		used[i] = true
+		first := l[0]
+		forward := []r2.Vec{l[0], l[1]}
+		closed := false
+		// Follow the chain forwards until we return to the first vertex or run out of segments.
+		for {
+			last := forward[len(forward)-1]
+			if last == first {
+				closed = true
+				forward = forward[:len(forward)-1]
+				break
+			}
+			j, ok := next(starts, last)
+			if !ok {
+				break
+			}
+			used[j] = true
+			forward = append(forward, lines[j][1])
+		}
+		if !closed {
+			// Open chain, follow the chain backwards from the first vertex.
+			var backward []r2.Vec
+			v := first
+			for {
+				j, ok := next(ends, v)
+				if !ok {
+					break
+				}
+				used[j] = true
+				v = lines[j][0]
+				backward = append(backward, v)
+			}
+			for k := 0; k < len(backward)/2; k++ {
+				backward[k], backward[len(backward)-1-k] = backward[len(backward)-1-k], backward[k]
+			}
+			forward = append(backward, forward...)
+		}
+		polylines = append(polylines, polyline{vertices: forward, closed: closed})
+	}
+	return polylines
+}
@@ -0,0 +1,289 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d3"
+	"github.com/soypat/sdf/query"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Camera describes the position and projection of the view of a preview.
+type Camera struct {
+	// Eye is the position of the camera.
+	Eye r3.Vec
+	// LookAt is the point at the center of the image.
+	LookAt r3.Vec
+	// Up is the direction which appears upwards in the image.
+	Up r3.Vec
+	// FOV is the vertical field of view in radians of a perspective projection.
+	FOV float64
+	// OrthoHeight is the height of the view in model units of an
+	// orthographic projection. It is used when FOV is zero.
+	OrthoHeight float64
+}
+
+// PreviewOption configures the shading of a preview image.
+type PreviewOption func(*preview)
+
+// PreviewColor sets the color of the model. Defaults to #468966.
+func PreviewColor(c color.Color) PreviewOption {
+	return func(pv *preview) { pv.color = rgbComponents(c) }
+}
+
+// PreviewBackground sets the color of pixels which do not show the
+// model. Defaults to #FFF8E3.
+func PreviewBackground(c color.Color) PreviewOption {
+	return func(pv *preview) { pv.background = rgbComponents(c) }
+}
+
+// PreviewLight sets the direction towards the light in model coordinates.
+func PreviewLight(dir r3.Vec) PreviewOption {
+	return func(pv *preview) { pv.light = r3.Unit(dir) }
+}
+
+// PreviewAmbientOcclusion sets the strength of ambient occlusion which
+// darkens creases and cavities. Zero disables it. Defaults to 1.
+func PreviewAmbientOcclusion(strength float64) PreviewOption {
+	return func(pv *preview) { pv.ao = strength }
+}
+
+// PreviewEdges draws the silhouette and sharp edges of the model
+// as lines of color c.
+func PreviewEdges(c color.Color) PreviewOption {
+	return func(pv *preview) {
+		pv.edges = true
+		pv.edgeColor = rgbComponents(c)
+	}
+}
+
+// PreviewWorkers sets the number of goroutines rendering tiles of the
+// image. Defaults to GOMAXPROCS.
+func PreviewWorkers(n int) PreviewOption {
+	if n < 1 {
+		panic("need at least one worker")
+	}
+	return func(pv *preview) { pv.workers = n }
+}
+
+// preview renders images of an SDF3 by sphere tracing a ray per pixel.
+type preview struct {
+	s             sdf.SDF3
+	cam           Camera
+	width, height int
+	// camera frame.
+	forward, right, up r3.Vec
+	// half height of the view plane at unit distance for
+	// perspective projections or of the view for orthographic ones.
+	halfHeight float64
+	// largest dimension of the model bounding box.
+	size float64
+
+	color, background, edgeColor [3]float64
+	light                        r3.Vec
+	ao                           float64
+	edges                        bool
+	workers                      int
+
+	// per pixel ray hits.
+	hit       []bool
+	depth     []float64
+	positions []r3.Vec
+	normals   []r3.Vec
+}
+
+// previewTileSize is the side length in pixels of the
+// square tiles of the image rendered by each worker.
+const previewTileSize = 32
+
+// Preview renders a shaded image of s as seen from cam by sphere tracing
+// the SDF without meshing it. Shading uses Lambert's cosine law with
+// ambient occlusion. It is well suited for thumbnails and quick checks of
+// a model. Rays stop marching once they are within 1e-4 times the size of
+// the model from the surface.
+func Preview(s sdf.SDF3, cam Camera, width, height int, opts ...PreviewOption) image.Image {
+	if width <= 0 || height <= 0 {
+		panic("preview image dimensions must be positive")
+	}
+	if cam.FOV <= 0 && cam.OrthoHeight <= 0 {
+		panic("camera needs a field of view or orthographic height")
+	}
+	pv := &preview{
+		s:          s,
+		cam:        cam,
+		width:      width,
+		height:     height,
+		size:       d3.Max(d3.Box(s.Bounds()).Size()),
+		color:      [3]float64{0x46 / 255., 0x89 / 255., 0x66 / 255.},
+		background: [3]float64{0xff / 255., 0xf8 / 255., 0xe3 / 255.},
+		light:      r3.Unit(r3.Vec{X: -0.75, Y: 1, Z: 0.25}),
+		ao:         1,
+		workers:    runtime.GOMAXPROCS(0),
+		hit:        make([]bool, width*height),
+		depth:      make([]float64, width*height),
+		positions:  make([]r3.Vec, width*height),
+		normals:    make([]r3.Vec, width*height),
+	}
+	for _, opt := range opts {
+		opt(pv)
+	}
+	pv.forward = r3.Unit(r3.Sub(cam.LookAt, cam.Eye))
+	pv.right = r3.Unit(r3.Cross(pv.forward, cam.Up))
+	pv.up = r3.Cross(pv.right, pv.forward)
+	if cam.FOV > 0 {
+		pv.halfHeight = math.Tan(cam.FOV / 2)
+	} else {
+		pv.halfHeight = cam.OrthoHeight / 2
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	pv.forTiles(pv.traceTile)
+	pv.forTiles(func(tile image.Rectangle) { pv.shadeTile(img, tile) })
+	return img
+}
+
+// forTiles calls fn concurrently for all tiles of the image.
+func (pv *preview) forTiles(fn func(tile image.Rectangle)) {
+	tiles := make(chan image.Rectangle)
+	var wg sync.WaitGroup
+	for i := 0; i < pv.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tile := range tiles {
+				fn(tile)
+			}
+		}()
+	}
+	bounds := image.Rect(0, 0, pv.width, pv.height)
+	for y := 0; y < pv.height; y += previewTileSize {
+		for x := 0; x < pv.width; x += previewTileSize {
+			tiles <- image.Rect(x, y, x+previewTileSize, y+previewTileSize).Intersect(bounds)
+		}
+	}
+	close(tiles)
+	wg.Wait()
+}
+
+// traceTile finds the surface hit by the ray of each pixel of the tile.
+func (pv *preview) traceTile(tile image.Rectangle) {
+	opts := query.RaycastOptions{Epsilon: 1e-4 * pv.size}
+	for y := tile.Min.Y; y < tile.Max.Y; y++ {
+		for x := tile.Min.X; x < tile.Max.X; x++ {
+			origin, dir := pv.ray(x, y)
+			hit, ok := query.Raycast(pv.s, origin, dir, opts)
+			i := y*pv.width + x
+			pv.hit[i] = ok
+			if ok {
+				pv.depth[i] = hit.Distance
+				pv.positions[i] = hit.Position
+				pv.normals[i] = hit.Normal
+			}
+		}
+	}
+}
+
+// ray returns the ray through the center of pixel (x,y).
+func (pv *preview) ray(x, y int) (origin, dir r3.Vec) {
+	aspect := float64(pv.width) / float64(pv.height)
+	u := (2*(float64(x)+0.5)/float64(pv.width) - 1) * pv.halfHeight * aspect
+	v := (1 - 2*(float64(y)+0.5)/float64(pv.height)) * pv.halfHeight
+	offset := r3.Add(r3.Scale(u, pv.right), r3.Scale(v, pv.up))
+	if pv.cam.FOV > 0 {
+		return pv.cam.Eye, r3.Add(pv.forward, offset)
+	}
+	return r3.Add(pv.cam.Eye, offset), pv.forward
+}
+
+// shadeTile writes the colors of the pixels of the tile to img.
+func (pv *preview) shadeTile(img *image.RGBA, tile image.Rectangle) {
+	for y := tile.Min.Y; y < tile.Max.Y; y++ {
+		for x := tile.Min.X; x < tile.Max.X; x++ {
+			i := y*pv.width + x
+			var c [3]float64
+			switch {
+			case pv.edges && pv.isEdge(x, y):
+				c = pv.edgeColor
+			case !pv.hit[i]:
+				c = pv.background
+			default:
+				n := pv.normals[i]
+				lambert := math.Max(0, r3.Dot(n, pv.light))
+				intensity := 0.3 + 0.7*lambert
+				if pv.ao > 0 {
+					intensity *= pv.occlusion(pv.positions[i], n)
+				}
+				for k := range c {
+					c[k] = pv.color[k] * intensity
+				}
+			}
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(255*clamp01(c[0]) + 0.5),
+				G: uint8(255*clamp01(c[1]) + 0.5),
+				B: uint8(255*clamp01(c[2]) + 0.5),
+				A: 255,
+			})
+		}
+	}
+}
+
+// occlusion returns the fraction of ambient light reaching surface point p
+// with normal n estimated by sampling the SDF along the normal. Where the
+// SDF is smaller than the distance travelled nearby surfaces block light.
+func (pv *preview) occlusion(p, n r3.Vec) float64 {
+	const samples = 5
+	maxDist := 0.05 * pv.size
+	occ, total, weight := 0.0, 0.0, 1.0
+	for i := 1; i <= samples; i++ {
+		h := maxDist * float64(i) / samples
+		d := pv.s.Evaluate(r3.Add(p, r3.Scale(h, n)))
+		occ += weight * math.Max(0, h-d) / h
+		total += weight
+		weight *= 0.7
+	}
+	return clamp01(1 - pv.ao*occ/total)
+}
+
+// isEdge reports whether pixel (x,y) lies on the silhouette of the model
+// or on a sharp edge, found by comparing it with its neighbours.
+func (pv *preview) isEdge(x, y int) bool {
+	// Cosine of the angle between normals considered a sharp edge.
+	const cosCrease = 0.8
+	i := y*pv.width + x
+	for _, nb := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		nx, ny := x+nb[0], y+nb[1]
+		if nx < 0 || ny < 0 || nx >= pv.width || ny >= pv.height {
+			continue
+		}
+		j := ny*pv.width + nx
+		if pv.hit[i] != pv.hit[j] {
+			// Draw silhouettes on the model side.
+			return pv.hit[i]
+		}
+		if !pv.hit[i] {
+			continue
+		}
+		if r3.Dot(pv.normals[i], pv.normals[j]) < cosCrease {
+			return true
+		}
+		// Depth discontinuity of overlapping parts. Only the pixel
+		// closer to the camera is drawn.
+		if pv.depth[j]-pv.depth[i] > 0.05*pv.size {
+			return true
+		}
+	}
+	return false
+}
+
+func rgbComponents(c color.Color) [3]float64 {
+	r, g, b, _ := c.RGBA()
+	return [3]float64{float64(r) / 0xffff, float64(g) / 0xffff, float64(b) / 0xffff}
+}
+
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
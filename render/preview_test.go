@@ -0,0 +1,66 @@
+package render_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestPreview(t *testing.T) {
+	const size = 64
+	sphere := must3.Sphere(1)
+	background := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	cameras := map[string]render.Camera{
+		"perspective":  {Eye: r3.Vec{Z: 5}, Up: r3.Vec{Y: 1}, FOV: 40 * math.Pi / 180},
+		"orthographic": {Eye: r3.Vec{Z: 5}, Up: r3.Vec{Y: 1}, OrthoHeight: 3},
+	}
+	for name, cam := range cameras {
+		img := render.Preview(sphere, cam, size, size, render.PreviewBackground(background),
+			render.PreviewLight(r3.Vec{Z: 1}), render.PreviewWorkers(3)).(*image.RGBA)
+		if got := img.RGBAAt(0, 0); got != background {
+			t.Errorf("%s: corner pixel %v is not background", name, got)
+		}
+		// Center of the sphere faces the light and is fully lit.
+		if got := img.RGBAAt(size/2, size/2); got != (color.RGBA{R: 0x46, G: 0x89, B: 0x66, A: 255}) {
+			t.Errorf("%s: center pixel %v is not model color", name, got)
+		}
+		// Same image regardless of the number of workers.
+		single := render.Preview(sphere, cam, size, size, render.PreviewBackground(background),
+			render.PreviewLight(r3.Vec{Z: 1}), render.PreviewWorkers(1)).(*image.RGBA)
+		if !bytes.Equal(img.Pix, single.Pix) {
+			t.Errorf("%s: image depends on number of workers", name)
+		}
+	}
+}
+
+func TestPreviewEdges(t *testing.T) {
+	const size = 64
+	edge := color.RGBA{R: 255, A: 255}
+	cam := render.Camera{Eye: r3.Vec{X: 4, Y: 3, Z: 5}, Up: r3.Vec{Z: 1}, OrthoHeight: 4}
+	img := render.Preview(must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0), cam, size, size,
+		render.PreviewEdges(edge), render.PreviewAmbientOcclusion(0)).(*image.RGBA)
+	// Every row crossing the box starts with an edge pixel.
+	rows := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := img.RGBAAt(x, y)
+			if c == img.RGBAAt(0, 0) {
+				continue
+			}
+			rows++
+			if c != edge {
+				t.Errorf("first model pixel of row %d is %v, want edge color", y, c)
+			}
+			break
+		}
+	}
+	if rows == 0 {
+		t.Fatal("box not visible")
+	}
+}
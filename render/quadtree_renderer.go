@@ -0,0 +1,197 @@
+package render
+
+import (
+	"io"
+	"math"
+	"sync"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// quadtree renders SDF2 outlines using marching squares with quadtree space sampling.
+type quadtree struct {
+	dc        dc2
+	todo      []square
+	unwritten LineBuffer
+	// Number of lines generated.
+	lines int
+}
+
+type square struct {
+	sdf.V2i      // origin of square as integers
+	n       uint // level of square, size = 1 << n
+}
+
+// NewQuadRenderer returns a Marching Squares implementation using quadtree
+// square sampling. It is the 2D counterpart of NewOctreeRenderer. Squares
+// are processed depth first so memory usage stays proportional to the
+// depth of the quadtree instead of its area.
+func NewQuadRenderer(s sdf.SDF2, meshCells int) *quadtree {
+	if meshCells < 2 {
+		panic("meshCells must be 2 or larger")
+	}
+	// Scale the bounding box about the center to make sure the boundaries
+	// aren't on the object surface.
+	bb := d2.Box(s.Bounds())
+	bb = bb.ScaleAboutCenter(1.01)
+	longAxis := d2.Max(bb.Size())
+	// We want to test the smallest square (side == resolution) for emptiness
+	// so the level = 0 square is at half resolution.
+	resolution := 0.5 * longAxis / float64(meshCells)
+
+	// how many square levels for the quadtree?
+	levels := uint(math.Ceil(math.Log2(longAxis/resolution))) + 1
+
+	todo := make([]square, 1, 4*levels)
+	todo[0] = square{sdf.V2i{0, 0}, levels - 1} // process the quadtree, start at the top level
+	return &quadtree{
+		dc:        *newDc2(s, bb.Min, resolution, levels),
+		unwritten: LineBuffer{buf: make([][2]r2.Vec, 0, marchingSquaresMaxLines)},
+		todo:      todo,
+	}
+}
+
+// ReadLines writes line segments rendered from the model into the argument buffer.
+// returns number of lines written and an error if present.
+func (q *quadtree) ReadLines(dst [][2]r2.Vec) (n int, err error) {
+	if len(dst) == 0 {
+		panic("cannot write to empty line slice")
+	}
+	if q.unwritten.Len() > 0 {
+		n += q.unwritten.Read(dst[n:])
+		if n == len(dst) {
+			return n, nil
+		}
+	}
+	if len(q.todo) == 0 && q.unwritten.Len() == 0 {
+		if n > 0 {
+			// Return lines read and report io.EOF on next call.
+			return n, nil
+		}
+		// Done rendering model.
+		return 0, io.EOF
+	}
+	for len(q.todo) > 0 && n < len(dst) {
+		// Pop the last square from the stack.
+		sq := q.todo[len(q.todo)-1]
+		q.todo = q.todo[:len(q.todo)-1]
+		if n+marchingSquaresMaxLines > len(dst) {
+			// Not enough room in buffer to write all lines that could be found by marching squares.
+			var tmp [marchingSquaresMaxLines][2]r2.Vec
+			nl := q.processSquare(tmp[:], sq)
+			nc := copy(dst[n:], tmp[:nl])
+			q.unwritten.Write(tmp[nc:nl])
+			n += nc
+			continue
+		}
+		n += q.processSquare(dst[n:], sq)
+	}
+	return n, nil
+}
+
+// processSquare generates lines if the square is at the required resolution
+// or adds the non-empty sub squares to the todo stack.
+func (q *quadtree) processSquare(dst [][2]r2.Vec, sq square) (writtenLines int) {
+	if sq.n == 1 {
+		// this square is at the required resolution
+		c0, d0 := q.dc.Evaluate(sq.Add(sdf.V2i{0, 0}))
+		c1, d1 := q.dc.Evaluate(sq.Add(sdf.V2i{2, 0}))
+		c2, d2 := q.dc.Evaluate(sq.Add(sdf.V2i{2, 2}))
+		c3, d3 := q.dc.Evaluate(sq.Add(sdf.V2i{0, 2}))
+		_, dCenter := q.dc.Evaluate(sq.Add(sdf.V2i{1, 1}))
+		corners := [4]r2.Vec{c0, c1, c2, c3}
+		values := [4]float64{d0, d1, d2, d3}
+		writtenLines = msToLines(dst, corners, values, dCenter, 0)
+		q.lines += writtenLines
+		return writtenLines
+	}
+	// process the sub squares. They are pushed in reverse
+	// order so that they are popped in counter-clockwise order.
+	n := sq.n - 1
+	s := 1 << n
+	subSquares := [4]square{
+		{sq.Add(sdf.V2i{0, s}), n},
+		{sq.Add(sdf.V2i{s, s}), n},
+		{sq.Add(sdf.V2i{s, 0}), n},
+		{sq.Add(sdf.V2i{0, 0}), n},
+	}
+	// Eliminate empty squares.
+	for _, candidate := range subSquares {
+		if !q.dc.IsEmpty(&candidate) {
+			q.todo = append(q.todo, candidate)
+		}
+	}
+	return 0
+}
+
+// dc2 implements a 2 dimensional distance cache. Evaluates the SDF2 via a
+// distance cache to avoid repeated evaluations on shared square corners.
+type dc2 struct {
+	mu         sync.Mutex          // lock the the cache during reads/writes
+	cache      map[sdf.V2i]float64 // cache of distances
+	origin     r2.Vec              // origin of the overall bounding square
+	resolution float64             // size of smallest quadtree square
+	hdiag      []float64           // lookup table of square half diagonals
+	s          sdf.SDF2            // the SDF2 to be rendered
+}
+
+// Evaluate returns the position and the SDF2 distance of a grid point.
+func (dc *dc2) Evaluate(vi sdf.V2i) (r2.Vec, float64) {
+	v := r2.Add(dc.origin, r2.Scale(dc.resolution, r2.Vec{X: float64(vi[0]), Y: float64(vi[1])}))
+	// do we have it in the cache?
+	dist, found := dc.read(vi)
+	if found {
+		return v, dist
+	}
+	// evaluate the SDF2
+	dist = dc.s.Evaluate(v)
+	// write it to the cache
+	dc.write(vi, dist)
+	return v, dist
+}
+
+// IsEmpty returns true if the square contains no SDF surface
+func (dc *dc2) IsEmpty(c *square) bool {
+	// evaluate the SDF2 at the center of the square
+	s := 1 << (c.n - 1) // half side
+	_, d := dc.Evaluate(c.AddScalar(s))
+	// compare to the center/corner distance
+	return math.Abs(d) >= dc.hdiag[c.n]
+}
+
+func newDc2(s sdf.SDF2, origin r2.Vec, resolution float64, n uint) *dc2 {
+	if n >= 64 {
+		panic("size of n must be less than size of word for hdiag generation")
+	}
+	dc := dc2{
+		origin:     origin,
+		resolution: resolution,
+		hdiag:      make([]float64, n),
+		s:          s,
+		cache:      make(map[sdf.V2i]float64),
+	}
+	// build a lut for square half diagonal lengths
+	for i := range dc.hdiag {
+		si := 1 << uint(i)
+		s := float64(si) * dc.resolution
+		dc.hdiag[i] = 0.5 * math.Sqrt(2.0*s*s)
+	}
+	return &dc
+}
+
+// read from the cache
+func (dc *dc2) read(vi sdf.V2i) (float64, bool) {
+	dc.mu.Lock()
+	dist, found := dc.cache[vi]
+	dc.mu.Unlock()
+	return dist, found
+}
+
+// write to the cache
+func (dc *dc2) write(vi sdf.V2i, dist float64) {
+	dc.mu.Lock()
+	dc.cache[vi] = dist
+	dc.mu.Unlock()
+}
@@ -0,0 +1,106 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	form2 "github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestQuadRendererCircle(t *testing.T) {
+	const (
+		radius  = 3.0
+		quality = 100
+	)
+	lines, err := render.RenderAllLines(render.NewQuadRenderer(form2.Circle(radius), quality))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) == 0 {
+		t.Fatal("no lines rendered")
+	}
+	tol := 2 * radius / quality
+	for _, l := range lines {
+		for _, v := range l {
+			if math.Abs(r2.Norm(v)-radius) > tol {
+				t.Fatalf("line vertex %v not on circle of radius %g", v, radius)
+			}
+		}
+	}
+	// Segments are oriented counter-clockwise around the inside of the contour
+	// so the signed area must be positive and approximately pi*r^2.
+	got := signedArea(lines)
+	want := math.Pi * radius * radius
+	if math.Abs(got-want)/want > 1e-2 {
+		t.Errorf("circle area got %g, want %g", got, want)
+	}
+	assertClosed(t, lines)
+}
+
+func TestQuadRendererHole(t *testing.T) {
+	const quality = 200
+	s := sdf.Difference2D(form2.Box(r2.Vec{X: 4, Y: 4}, 0), form2.Circle(1))
+	lines, err := render.RenderAllLines(render.NewQuadRenderer(s, quality))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := signedArea(lines)
+	want := 16 - math.Pi
+	if math.Abs(got-want)/want > 1e-2 {
+		t.Errorf("area with hole got %g, want %g", got, want)
+	}
+	assertClosed(t, lines)
+}
+
+func TestQuadRendererSmallBuffer(t *testing.T) {
+	r := render.NewQuadRenderer(form2.Circle(1), 50)
+	all, err := render.RenderAllLines(render.NewQuadRenderer(form2.Circle(1), 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][2]r2.Vec
+	buf := make([][2]r2.Vec, 1)
+	for {
+		n, err := r.ReadLines(buf)
+		got = append(got, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	if len(got) != len(all) {
+		t.Fatalf("read %d lines with single line buffer, want %d", len(got), len(all))
+	}
+	for i := range got {
+		if got[i] != all[i] {
+			t.Fatalf("line %d mismatch: got %v, want %v", i, got[i], all[i])
+		}
+	}
+}
+
+// signedArea calculates the area enclosed by a set of oriented line segments
+// using the shoelace formula.
+func signedArea(lines [][2]r2.Vec) float64 {
+	var area float64
+	for _, l := range lines {
+		area += r2.Cross(l[0], l[1])
+	}
+	return area / 2
+}
+
+// assertClosed checks every segment start is the end of another segment.
+func assertClosed(t *testing.T, lines [][2]r2.Vec) {
+	t.Helper()
+	ends := make(map[r2.Vec]int)
+	for _, l := range lines {
+		ends[l[1]]++
+	}
+	for _, l := range lines {
+		if ends[l[0]] == 0 {
+			t.Fatalf("segment %v start is not connected to a segment end", l)
+		}
+		ends[l[0]]--
+	}
+}
@@ -1,9 +1,17 @@
 package render
 
 import (
+	"gonum.org/v1/gonum/spatial/r2"
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
 type Renderer interface {
 	ReadTriangles(t []r3.Triangle) (int, error)
 }
+
+// Renderer2 is the 2D analogue of Renderer. It streams the line segments
+// that make up the outline of an SDF2. Segments are oriented so that the
+// interior of the shape lies to the left of the direction of travel.
+type Renderer2 interface {
+	ReadLines(l [][2]r2.Vec) (int, error)
+}
@@ -1,6 +1,7 @@
 package render_test
 
 import (
+	"image/png"
 	"math/rand"
 	"os"
 	"runtime/pprof"
@@ -11,6 +12,7 @@ import (
 	"github.com/soypat/sdf/form3/obj3/thread"
 	"github.com/soypat/sdf/internal/d3"
 	"github.com/soypat/sdf/render"
+	"github.com/soypat/sdf/render/rendertest"
 	"gonum.org/v1/gonum/spatial/r2"
 	"gonum.org/v1/gonum/spatial/r3"
 )
@@ -61,12 +63,25 @@ func testStressProfile(t *testing.T) {
 	stlStressTest(t, stlName)
 	defer os.Remove(stlName)
 	pprof.StopCPUProfile()
-	stlToPNG(t, stlName, "stress.png", viewConfig{
-		up:     r3.Vec{Z: 1},
-		eyepos: d3.Elem(3),
-		near:   1,
-		far:    10,
-	}) // visualization just in case
+	// visualization just in case
+	fp, err := os.Open(stlName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	model, err := render.ReadSTL(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.Create("stress.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	err = png.Encode(out, rendertest.Image(model, rendertest.DefaultView()))
+	if err != nil {
+		t.Fatal(err)
+	}
 }
 
 func stlStressTest(t testing.TB, filename string) {
@@ -0,0 +1,163 @@
+package rendertest
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fogleman/fauxgl"
+	"github.com/nfnt/resize"
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+	"gonum.org/v1/plot/cmpimg"
+)
+
+// Quality is the number of cells along the largest dimension of the
+// octree renderer used by AssertMatchesGolden.
+const Quality = 200
+
+// View describes the camera used to render an image of a model.
+// Models are scaled to fit in a bi-unit cube centered at the origin
+// before being viewed.
+type View struct {
+	// Eye is the position of the camera.
+	Eye r3.Vec
+	// LookAt is the point the camera looks at.
+	LookAt r3.Vec
+	// Up is the direction which appears upwards in the image.
+	Up r3.Vec
+	// Near and Far are the distances of the clipping planes.
+	Near, Far float64
+	// Width and Height of the image in pixels. Defaults to 1920x1080.
+	Width, Height int
+}
+
+// DefaultView returns the isometric view used by the sdf tests.
+func DefaultView() View {
+	return View{
+		Up:   r3.Vec{Z: 1},
+		Eye:  d3.Elem(3),
+		Near: 1,
+		Far:  10,
+	}
+}
+
+// AssertMatchesGolden renders s with the octree renderer and fails the test
+// if the image of the resulting mesh does not match the golden image file
+// at goldenPath pixel by pixel. If update is true the golden image is
+// created or overwritten instead. Tests usually set update from a flag:
+//
+//	var update = flag.Bool("update", false, "update golden images")
+//
+// On mismatch the rendered image is saved next to the golden image
+// with a _got.png suffix for inspection.
+func AssertMatchesGolden(t testing.TB, s sdf.SDF3, view View, goldenPath string, update bool) {
+	t.Helper()
+	AssertRendererMatchesGolden(t, render.NewOctreeRenderer(s, Quality), view, goldenPath, update)
+}
+
+// AssertRendererMatchesGolden is like AssertMatchesGolden
+// but renders the mesh with the Renderer r.
+func AssertRendererMatchesGolden(t testing.TB, r render.Renderer, view View, goldenPath string, update bool) {
+	t.Helper()
+	model, err := render.RenderAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	err = png.Encode(&got, Image(model, view))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update {
+		err = os.WriteFile(goldenPath, got.Bytes(), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("updated golden image %s", goldenPath)
+		return
+	}
+	golden, err := os.ReadFile(goldenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("golden image %s does not exist, set update to create it", goldenPath)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	equal, err := cmpimg.Equal("png", got.Bytes(), golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		// Keep the rendered image for inspection.
+		gotPath := strings.TrimSuffix(goldenPath, ".png") + "_got.png"
+		err = os.WriteFile(gotPath, got.Bytes(), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Errorf("rendered image %s does not match golden image %s", gotPath, goldenPath)
+	}
+}
+
+// Image returns a Phong shaded image of the model triangles. Vertices are
+// rounded to single precision as they would be when saved to an STL file.
+func Image(model []r3.Triangle, view View) image.Image {
+	const (
+		scale = 1  // optional supersampling
+		fovy  = 30 // vertical field of view in degrees
+	)
+	width, height := view.Width, view.Height
+	if width == 0 || height == 0 {
+		width, height = 1920, 1080
+	}
+	var (
+		eye    = fauxgl.V(view.Eye.X, view.Eye.Y, view.Eye.Z)          // camera position
+		center = fauxgl.V(view.LookAt.X, view.LookAt.Y, view.LookAt.Z) // view center position
+		up     = fauxgl.V(view.Up.X, view.Up.Y, view.Up.Z)             // up vector
+		light  = fauxgl.V(-0.75, 1, 0.25).Normalize()                  // light direction
+		color  = fauxgl.HexColor("#468966")                            // object color
+	)
+	mesh := fauxglMesh(model)
+	// fit mesh in a bi-unit cube centered at the origin
+	mesh.BiUnitCube()
+	// create a rendering context
+	context := fauxgl.NewContext(width*scale, height*scale)
+	context.ClearColorBufferWith(fauxgl.HexColor("#FFF8E3"))
+	// create transformation matrix and light direction
+	aspect := float64(width) / float64(height)
+	matrix := fauxgl.LookAt(eye, center, up).Perspective(fovy, aspect, view.Near, view.Far)
+	// use builtin phong shader
+	shader := fauxgl.NewPhongShader(matrix, light, eye)
+	shader.ObjectColor = color
+	context.Shader = shader
+	// render
+	context.DrawMesh(mesh)
+	// downsample image for antialiasing
+	return resize.Resize(uint(width), uint(height), context.Image(), resize.Bilinear)
+}
+
+// fauxglMesh converts triangles to a flat shaded fauxgl mesh.
+func fauxglMesh(model []r3.Triangle) *fauxgl.Mesh {
+	triangles := make([]*fauxgl.Triangle, len(model))
+	for i, tri := range model {
+		t := &fauxgl.Triangle{}
+		t.V1.Position = fauxglVec(tri[0])
+		t.V2.Position = fauxglVec(tri[1])
+		t.V3.Position = fauxglVec(tri[2])
+		n := t.Normal()
+		t.V1.Normal = n
+		t.V2.Normal = n
+		t.V3.Normal = n
+		triangles[i] = t
+	}
+	return fauxgl.NewTriangleMesh(triangles)
+}
+
+func fauxglVec(v r3.Vec) fauxgl.Vector {
+	return fauxgl.V(float64(float32(v.X)), float64(float32(v.Y)), float64(float32(v.Z)))
+}
@@ -1,6 +1,7 @@
 package render
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/binary"
 	"errors"
@@ -8,6 +9,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"strconv"
 
 	"github.com/chewxy/math32"
 	"gonum.org/v1/gonum/spatial/r3"
@@ -65,6 +67,200 @@ func WriteSTL(w io.Writer, model []r3.Triangle) error {
 	return nil
 }
 
+// CreateSTLASCII renders an SDF3 as an ASCII STL file using a Renderer.
+// ASCII STL files are several times larger than their binary counterparts
+// and should only be used when the consumer does not support binary STL.
+func CreateSTLASCII(path string, r Renderer) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	var (
+		buf [trianglesInBuffer]r3.Triangle
+		nt  int
+	)
+	w.WriteString("solid " + stlASCIIName + "\n")
+	for err == nil {
+		nt, err = r.ReadTriangles(buf[:])
+		writeSTLASCIIFacets(w, buf[:nt])
+	}
+	if err != io.EOF {
+		return err
+	}
+	w.WriteString("endsolid " + stlASCIIName + "\n")
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// WriteSTLASCII writes model triangles to a writer in ASCII STL file format.
+func WriteSTLASCII(w io.Writer, model []r3.Triangle) error {
+	if len(model) == 0 {
+		return errors.New("empty triangle slice")
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("solid " + stlASCIIName + "\n")
+	writeSTLASCIIFacets(bw, model)
+	bw.WriteString("endsolid " + stlASCIIName + "\n")
+	return bw.Flush()
+}
+
+const stlASCIIName = "sdf"
+
+func writeSTLASCIIFacets(w *bufio.Writer, model []r3.Triangle) {
+	for _, t := range model {
+		n := r3.Unit(t.Normal())
+		w.WriteString("facet normal " + stlASCIIVec(n) + "\n outer loop\n")
+		for _, v := range t {
+			w.WriteString("  vertex " + stlASCIIVec(v) + "\n")
+		}
+		w.WriteString(" endloop\nendfacet\n")
+	}
+}
+
+func stlASCIIVec(v r3.Vec) string {
+	return strconv.FormatFloat(v.X, 'e', -1, 64) + " " +
+		strconv.FormatFloat(v.Y, 'e', -1, 64) + " " +
+		strconv.FormatFloat(v.Z, 'e', -1, 64)
+}
+
+// ReadSTL reads triangles from an STL file. Both binary and ASCII
+// STL formats are supported and detected automatically. The normals stored
+// in the file are ignored since the triangle winding defines the normal.
+func ReadSTL(r io.Reader) ([]r3.Triangle, error) {
+	br := bufio.NewReader(r)
+	if isASCIISTL(br) {
+		return readASCIISTL(br)
+	}
+	model, err := readBinarySTL(br)
+	if errors.Is(err, errCalculatedNormalMismatch) {
+		err = nil
+	}
+	return model, err
+}
+
+// isASCIISTL reports whether the buffered reader contents look like an
+// ASCII STL file. Binary STL headers may also begin with "solid" so the
+// first bytes are also checked for a facet or end of solid keyword and for
+// non-text characters.
+func isASCIISTL(br *bufio.Reader) bool {
+	start, _ := br.Peek(512)
+	start = bytes.TrimLeft(start, " \t\r\n")
+	if !bytes.HasPrefix(start, []byte("solid")) {
+		return false
+	}
+	if !bytes.Contains(start, []byte("facet")) && !bytes.Contains(start, []byte("endsolid")) {
+		return false
+	}
+	for _, c := range start {
+		if c >= 0x80 || (c < ' ' && c != '\t' && c != '\r' && c != '\n') {
+			return false
+		}
+	}
+	return true
+}
+
+// readASCIISTL parses the ASCII STL format. Files containing
+// several consecutive solids are supported.
+func readASCIISTL(r io.Reader) (output []r3.Triangle, err error) {
+	sc := bufio.NewScanner(r)
+	sc.Split(bufio.ScanWords)
+	next := func() string {
+		if sc.Scan() {
+			return sc.Text()
+		}
+		return ""
+	}
+	expect := func(keywords ...string) error {
+		for _, kw := range keywords {
+			if tok := next(); tok != kw {
+				if tok == "" {
+					return io.ErrUnexpectedEOF
+				}
+				return fmt.Errorf("expected %q, got %q", kw, tok)
+			}
+		}
+		return nil
+	}
+	vec := func() (v r3.Vec, err error) {
+		var f [3]float64
+		for i := range f {
+			tok := next()
+			if tok == "" {
+				return v, io.ErrUnexpectedEOF
+			}
+			f[i], err = strconv.ParseFloat(tok, 64)
+			if err != nil {
+				return v, err
+			}
+			if math.IsNaN(f[i]) || math.IsInf(f[i], 0) {
+				return v, errors.New("inf/NaN STL triangle vertex")
+			}
+		}
+		return r3.Vec{X: f[0], Y: f[1], Z: f[2]}, nil
+	}
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%d ASCII STL triangles read: %w", len(output), err)
+		}
+	}()
+
+	if err := expect("solid"); err != nil {
+		return nil, err
+	}
+	inSolid := true
+	inName := true // solid names are ignored.
+	for {
+		tok := next()
+		switch {
+		case tok == "":
+			if err := sc.Err(); err != nil {
+				return nil, err
+			}
+			if inSolid {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if len(output) == 0 {
+				return nil, errors.New("STL file contains 0 triangles")
+			}
+			return output, nil
+		case tok == "solid" && !inSolid:
+			inSolid, inName = true, true
+		case tok == "endsolid" && inSolid:
+			inSolid, inName = false, true
+		case tok == "facet" && inSolid:
+			inName = false
+			if err := expect("normal"); err != nil {
+				return nil, err
+			}
+			if _, err := vec(); err != nil {
+				return nil, err
+			}
+			if err := expect("outer", "loop"); err != nil {
+				return nil, err
+			}
+			var t r3.Triangle
+			for i := range t {
+				if err := expect("vertex"); err != nil {
+					return nil, err
+				}
+				if t[i], err = vec(); err != nil {
+					return nil, err
+				}
+			}
+			if err := expect("endloop", "endfacet"); err != nil {
+				return nil, err
+			}
+			output = append(output, t)
+		case !inName:
+			return nil, fmt.Errorf("unexpected %q in STL solid", tok)
+		}
+	}
+}
+
 // stlHeader defines the STL file header.
 type stlHeader struct {
 	_     [80]uint8 // Header
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/soypat/sdf/form3"
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/internal/d3"
 	"github.com/soypat/sdf/render"
 	"gonum.org/v1/gonum/spatial/r3"
 )
@@ -41,3 +44,76 @@ func TestSTLCreateWriteRead(t *testing.T) {
 		t.Fatal("WriteSTL and CreateSTL output mismatch")
 	}
 }
+
+func TestReadSTLDetectFormat(t *testing.T) {
+	const tol = 1e-4
+	model, err := render.RenderAll(render.NewOctreeRenderer(must3.Sphere(5), 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name  string
+		write func(b *bytes.Buffer) error
+	}{
+		{name: "binary", write: func(b *bytes.Buffer) error { return render.WriteSTL(b, model) }},
+		{name: "ascii", write: func(b *bytes.Buffer) error { return render.WriteSTLASCII(b, model) }},
+	} {
+		var b bytes.Buffer
+		err := test.write(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := render.ReadSTL(&b)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if len(got) != len(model) {
+			t.Fatalf("%s: got %d triangles, want %d", test.name, len(got), len(model))
+		}
+		for i := range model {
+			for j := range model[i] {
+				if !d3.EqualWithin(got[i][j], model[i][j], tol) {
+					t.Fatalf("%s: %dth triangle mismatch. got %v, want %v", test.name, i, got[i], model[i])
+				}
+			}
+		}
+	}
+}
+
+func TestReadSTLASCII(t *testing.T) {
+	// Two solids with multi word names and irregular whitespace.
+	const stl = `solid supplier part A
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid supplier part A
+solid B
+facet normal 0 0 -1 outer loop vertex 0 0 0 vertex 0 1e0 0 vertex 1.0E+00 0 0 endloop endfacet
+endsolid B
+`
+	got, err := render.ReadSTL(strings.NewReader(stl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []r3.Triangle{
+		{{}, {X: 1}, {Y: 1}},
+		{{}, {Y: 1}, {X: 1}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d triangles, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%dth triangle got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	_, err = render.ReadSTL(strings.NewReader("solid A\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nendloop\nendfacet\nendsolid A\n"))
+	if err == nil {
+		t.Error("expected error for malformed facet")
+	}
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// CreateSVG renders an SDF2 as an SVG file using a Renderer2. To write an
+// SDF2 directly at a given resolution use NewQuadRenderer:
+//
+//	CreateSVG("shape.svg", NewQuadRenderer(s, 400))
+func CreateSVG(path string, r Renderer2) error {
+	lines, err := RenderAllLines(r)
+	if err != nil {
+		return err
+	}
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	err = WriteSVG(fp, lines)
+	if err != nil {
+		return err
+	}
+	return fp.Close()
+}
+
+// WriteSVG writes line segments to a writer in SVG file format. Segments
+// that share end points are joined into subpaths of a single <path> element
+// which uses the even-odd fill rule so holes in the shape are not filled.
+// The SVG user unit is the millimetre and the Y axis is flipped so the
+// drawing is not mirrored with respect to the SDF2.
+func WriteSVG(w io.Writer, lines [][2]r2.Vec) error {
+	if len(lines) == 0 {
+		return errors.New("empty line slice")
+	}
+	bb := r2.Box{Min: lines[0][0], Max: lines[0][0]}
+	for _, l := range lines {
+		for _, v := range l {
+			bb.Min = r2.Vec{X: math.Min(bb.Min.X, v.X), Y: math.Min(bb.Min.Y, v.Y)}
+			bb.Max = r2.Vec{X: math.Max(bb.Max.X, v.X), Y: math.Max(bb.Max.Y, v.Y)}
+		}
+	}
+	size := r2.Sub(bb.Max, bb.Min)
+	// Leave a margin so the stroke is not clipped at the edges.
+	margin := 0.02 * math.Max(size.X, size.Y)
+	strokeWidth := 0.1 * margin
+	width := size.X + 2*margin
+	height := size.Y + 2*margin
+	// svgPos maps a point to SVG coordinates.
+	svgPos := func(v r2.Vec) (x, y string) {
+		return svgFloat(v.X - bb.Min.X + margin), svgFloat(bb.Max.Y - v.Y + margin)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%smm" height="%smm" viewBox="0 0 %s %s">`+"\n",
+		svgFloat(width), svgFloat(height), svgFloat(width), svgFloat(height))
+	fmt.Fprintf(bw, `<path fill="#468966" fill-rule="evenodd" stroke="black" stroke-width="%s" d="`, svgFloat(strokeWidth))
+	for _, pl := range joinLines(lines) {
+		for i, v := range pl.vertices {
+			x, y := svgPos(v)
+			if i == 0 {
+				bw.WriteString("M" + x + " " + y)
+			} else {
+				bw.WriteString(" L" + x + " " + y)
+			}
+		}
+		if pl.closed {
+			bw.WriteString(" Z")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString(`"/>` + "\n</svg>\n")
+	return bw.Flush()
+}
+
+func svgFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,65 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/soypat/sdf"
+	form2 "github.com/soypat/sdf/form2/must2"
+	form3 "github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestSVGEvenOddPath(t *testing.T) {
+	const quality = 100
+	s := sdf.Difference2D(form2.Box(r2.Vec{X: 4, Y: 3}, 0.5), form2.Circle(1))
+	lines, err := render.RenderAllLines(render.NewQuadRenderer(s, quality))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	err = render.WriteSVG(&b, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var svg struct {
+		XMLName xml.Name `xml:"svg"`
+		Paths   []struct {
+			FillRule string `xml:"fill-rule,attr"`
+			D        string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	err = xml.Unmarshal(b.Bytes(), &svg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svg.Paths) != 1 {
+		t.Fatalf("expected a single path, got %d", len(svg.Paths))
+	}
+	path := svg.Paths[0]
+	if path.FillRule != "evenodd" {
+		t.Errorf("expected evenodd fill rule, got %q", path.FillRule)
+	}
+	if got := strings.Count(path.D, "M"); got != 2 {
+		t.Errorf("expected 2 subpaths (outline and hole), got %d", got)
+	}
+	if got := strings.Count(path.D, "Z"); got != 2 {
+		t.Errorf("expected 2 closed subpaths, got %d", got)
+	}
+}
+
+func TestCreateSVGSlice(t *testing.T) {
+	const path = "slice.svg"
+	defer os.Remove(path)
+	object := sdf.Difference3D(form3.Box(r3.Vec{X: 3, Y: 3, Z: 3}, 0.2), form3.Sphere(1))
+	slice := sdf.Slice2D(object, r3.Vec{}, r3.Vec{Z: 1})
+	err := render.CreateSVG(path, render.NewQuadRenderer(slice, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -89,25 +89,29 @@ func (s *CutSDF2) Bounds() r2.Box {
 // TransformSDF2 transorms an SDF2 with rotation, translation and scaling.
 type TransformSDF2 struct {
 	sdf  SDF2
-	mInv m33
-	bb   r2.Box
+	mInv M33
+	// k scales distances so they remain a bound for scaling transforms.
+	k  float64
+	bb r2.Box
 }
 
 // Transform2D applies a transformation matrix to an SDF2.
-// Distance is *not* preserved with scaling.
-func Transform2D(sdf SDF2, m m33) SDF2 {
+// Distances are scaled by the minimum singular value of the matrix so
+// they remain a lower bound of the true distance when the matrix scales
+// non-uniformly or shears. Rotations and translations preserve distance.
+func Transform2D(sdf SDF2, m M33) SDF2 {
 	s := TransformSDF2{}
 	s.sdf = sdf
 	s.mInv = m.Inverse()
+	s.k = lipschitzScale(m.minSingularValue())
 	s.bb = m.MulBox(sdf.Bounds())
 	return &s
 }
 
 // Evaluate returns the minimum distance to a transformed SDF2.
-// Distance is *not* preserved with scaling.
 func (s *TransformSDF2) Evaluate(p r2.Vec) float64 {
 	q := s.mInv.MulPosition(p)
-	return s.sdf.Evaluate(q)
+	return s.k * s.sdf.Evaluate(q)
 }
 
 // BoundingBox returns the bounding box of a transformed SDF2.
@@ -217,13 +221,13 @@ func (s *array2) Bounds() r2.Box {
 type rotateUnion2 struct {
 	sdf  SDF2
 	num  int
-	step m33
+	step M33
 	min  MinFunc
 	bb   r2.Box
 }
 
 // RotateUnion2D returns a union of rotated SDF2s.
-func RotateUnion2D(sdf SDF2, num int, step m33) SDF2 {
+func RotateUnion2D(sdf SDF2, num int, step M33) SDF2 {
 	// check the number of steps
 	if num <= 0 {
 		return empty2From(sdf)
@@ -375,9 +379,17 @@ type union2 struct {
 	sdf []SDF2
 	min MinFunc
 	bb  r2.Box
+	// bvh skips children far from the evaluated point.
+	// nil for small and blended unions.
+	bvh *bvh2
 }
 
 // Union2D returns the union of multiple SDF2 objects.
+//
+// Unions of many objects are evaluated using a bounding volume hierarchy
+// over the bounds of the objects so only those near the evaluated point are
+// evaluated. Blended unions (see SetMin) are evaluated without it since a
+// blend may depend on children far from the evaluated point.
 func Union2D(sdf ...SDF2) SDF2Union {
 	if len(sdf) <= 1 {
 		panic("union requires at least 2 sdfs")
@@ -395,11 +407,17 @@ func Union2D(sdf ...SDF2) SDF2Union {
 	}
 	s.bb = r2.Box(bb)
 	s.min = math.Min
+	if len(s.sdf) >= bvhMinChildren {
+		s.bvh = newBVH2(s.sdf)
+	}
 	return &s
 }
 
 // Evaluate returns the minimum distance to the SDF2 union.
 func (s *union2) Evaluate(p r2.Vec) float64 {
+	if s.bvh != nil {
+		return s.bvh.evaluate(s.sdf, p)
+	}
 	// work out the min/max distance for every bounding box
 	vs := make([]r2.Vec, len(s.sdf))
 	minDist2 := -1.0
@@ -448,6 +466,7 @@ func (s *union2) EvaluateSlow(p r2.Vec) float64 {
 // SetMin sets the minimum function to control SDF2 blending.
 func (s *union2) SetMin(min MinFunc) {
 	s.min = min
+	s.bvh = nil
 }
 
 // BoundingBox returns the bounding box of an SDF2 union.
@@ -345,13 +345,18 @@ func (s *loft3) Bounds() r3.Box {
 // transform3 is an SDF3 transformed with a 4x4 transformation matrix.
 type transform3 struct {
 	sdf     SDF3
-	matrix  m44
-	inverse m44
-	bb      r3.Box
+	matrix  M44
+	inverse M44
+	// k scales distances so they remain a bound for scaling transforms.
+	k  float64
+	bb r3.Box
 }
 
 // Transform3D applies a transformation matrix to an SDF3.
-func Transform3D(sdf SDF3, matrix m44) SDF3 {
+// Distances are scaled by the minimum singular value of the matrix so
+// they remain a lower bound of the true distance when the matrix scales
+// non-uniformly or shears. Rotations and translations preserve distance.
+func Transform3D(sdf SDF3, matrix M44) SDF3 {
 	if sdf == nil {
 		panic("nil SDF3 argument")
 	}
@@ -359,14 +364,14 @@ func Transform3D(sdf SDF3, matrix m44) SDF3 {
 	s.sdf = sdf
 	s.matrix = matrix
 	s.inverse = matrix.Inverse()
+	s.k = lipschitzScale(matrix.minSingularValue())
 	s.bb = matrix.MulBox(sdf.Bounds())
 	return &s
 }
 
 // Evaluate returns the minimum distance to a transformed SDF3.
-// Distance is *not* preserved with scaling.
 func (s *transform3) Evaluate(p r3.Vec) float64 {
-	return s.sdf.Evaluate(s.inverse.MulPosition(p))
+	return s.k * s.sdf.Evaluate(s.inverse.MulPosition(p))
 }
 
 // BoundingBox returns the bounding box of a transformed SDF3.
@@ -411,11 +416,19 @@ type union3 struct {
 	sdf []SDF3
 	min MinFunc
 	bb  r3.Box
+	// bvh skips children far from the evaluated point.
+	// nil for small and blended unions.
+	bvh *bvh3
 }
 
 // Union3D returns the union of multiple SDF3 objects.
 // Union3D will panic if arguments list is empty or if
 // an argument SDF3 is nil.
+//
+// Unions of many objects are evaluated using a bounding volume hierarchy
+// over the bounds of the objects so only those near the evaluated point are
+// evaluated. Blended unions (see SetMin) are evaluated without it since a
+// blend may depend on children far from the evaluated point.
 func Union3D(sdf ...SDF3) SDF3Union {
 	if len(sdf) < 2 {
 		panic("union require at least 2 sdfs")
@@ -435,11 +448,17 @@ func Union3D(sdf ...SDF3) SDF3Union {
 	}
 	s.bb = r3.Box(bb)
 	s.min = math.Min
+	if len(s.sdf) >= bvhMinChildren {
+		s.bvh = newBVH3(s.sdf)
+	}
 	return &s
 }
 
 // Evaluate returns the minimum distance to an SDF3 union.
 func (s *union3) Evaluate(p r3.Vec) float64 {
+	if s.bvh != nil {
+		return s.bvh.evaluate(s.sdf, p)
+	}
 	var d float64
 	for i, x := range s.sdf {
 		if i == 0 {
@@ -454,6 +473,7 @@ func (s *union3) Evaluate(p r3.Vec) float64 {
 // SetMin sets the minimum function to control blending.
 func (s *union3) SetMin(min MinFunc) {
 	s.min = min
+	s.bvh = nil
 }
 
 // BoundingBox returns the bounding box of an SDF3 union.
@@ -655,14 +675,14 @@ func (s *array3) Bounds() r3.Box {
 type rotateUnion struct {
 	sdf  SDF3
 	num  int
-	step m44
+	step M44
 	min  MinFunc
 	bb   r3.Box
 }
 
 // RotateUnion3D creates a union of SDF3s rotated about the z-axis.
 // num is the number of copies.
-func RotateUnion3D(sdf SDF3, num int, step m44) SDF3Union {
+func RotateUnion3D(sdf SDF3, num int, step M44) SDF3Union {
 	// check the number of steps
 	if num <= 0 {
 		return empty3From(sdf)
@@ -249,91 +249,20 @@ func ScaleTwistExtrude(height, twist float64, scale r2.Vec) ExtrudeFunc {
 	}
 }
 
-// Raycasting
-
-func sigmoidScaled(x float64) float64 {
-	return 2/(1+math.Exp(-x)) - 1
-}
-
-// raycast3 collides a ray (with an origin point from and a direction dir) with an SDF3.
-// sigmoid is useful for fixing bad distance functions (those that do not accurately represent the distance to the
-// closest surface, but will probably imply more evaluations)
-// stepScale controls precision (less stepSize, more precision, but more SDF evaluations): use 1 if SDF indicates
-// distance to the closest surface.
-// It returns the collision point, how many normalized distances to reach it (t), and the number of steps performed
-// If no surface is found (in maxDist and maxSteps), t is < 0
-func raycast3(s SDF3, from, dir r3.Vec, scaleAndSigmoid, stepScale, epsilon, maxDist float64, maxSteps int) (collision r3.Vec, t float64, steps int) {
-	t = 0
-	dirN := r3.Unit(dir)
-	pos := from
-	for {
-		val := math.Abs(s.Evaluate(pos))
-		//log.Print("Raycast step #", steps, " at ", pos, " with value ", val, "\n")
-		if val < epsilon {
-			collision = pos // Success
-			break
-		}
-		steps++
-		if steps == maxSteps {
-			t = -1 // Failure
-			break
-		}
-		if scaleAndSigmoid > 0 {
-			val = sigmoidScaled(val * 10)
-		}
-		delta := val * stepScale
-		t += delta
-		pos = r3.Add(pos, r3.Scale(delta, dirN))
-		if t < 0 || t > maxDist {
-			t = -1 // Failure
-			break
-		}
-	}
-	//log.Println("Raycast did", steps, "steps")
-	return
-}
-
-// raycast2 see Raycast3. NOTE: implementation using Raycast3 (inefficient?)
-func raycast2(s SDF2, from, dir r2.Vec, scaleAndSigmoid, stepScale, epsilon, maxDist float64, maxSteps int) (r2.Vec, float64, int) {
-	collision, t, steps := raycast3(Extrude3D(s, 1), r3.Vec{X: from.X, Y: from.Y, Z: 0}, r3.Vec{X: dir.X, Y: dir.Y, Z: 0}, scaleAndSigmoid, stepScale, epsilon, maxDist, maxSteps)
-	return r2.Vec{X: collision.X, Y: collision.Y}, t, steps
-}
-
-// Normals
-
-// normal3 returns the normal of an SDF3 at a point (doesn't need to be on the surface).
-// Computed by sampling it several times inside a box of side 2*eps centered on p.
-func normal3(s SDF3, p r3.Vec, eps float64) r3.Vec {
-	return r3.Unit(r3.Vec{
-		X: s.Evaluate(r3.Add(p, r3.Vec{X: eps})) - s.Evaluate(r3.Add(p, r3.Vec{X: -eps})),
-		Y: s.Evaluate(r3.Add(p, r3.Vec{Y: eps})) - s.Evaluate(r3.Add(p, r3.Vec{Y: -eps})),
-		Z: s.Evaluate(r3.Add(p, r3.Vec{Z: eps})) - s.Evaluate(r3.Add(p, r3.Vec{Z: -eps})),
-	})
-}
-
-// normal2 returns the normal of an SDF3 at a point (doesn't need to be on the surface).
-// Computed by sampling it several times inside a box of side 2*eps centered on p.
-func normal2(s SDF2, p r2.Vec, eps float64) r2.Vec {
-	return r2.Unit(r2.Vec{
-		X: s.Evaluate(r2.Add(p, r2.Vec{X: eps})) - s.Evaluate(r2.Add(p, r2.Vec{X: -eps})),
-		Y: s.Evaluate(r2.Add(p, r2.Vec{Y: eps})) - s.Evaluate(r2.Add(p, r2.Vec{Y: -eps})),
-	})
-}
-
 // Floating Point Comparisons
 // See: http://floating-point-gui.de/errors/NearlyEqualsTest.java
 
 const minNormal = 2.2250738585072014e-308 // 2**-1022
 
 // MulVertices multiples a set of V2 vertices by a rotate/translate matrix.
-func mulVertices2(v d2.Set, a m33) {
+func mulVertices2(v d2.Set, a M33) {
 	for i := range v {
 		v[i] = a.MulPosition(v[i])
 	}
 }
 
 // MulVertices multiples a set of r3.Vec vertices by a rotate/translate matrix.
-func mulVertices3(v d3.Set, a m44) {
+func mulVertices3(v d3.Set, a M44) {
 	for i := range v {
 		v[i] = a.MulPosition(v[i])
 	}